@@ -0,0 +1,57 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"battleship/pkg/database"
+)
+
+// AuditCommand handles auditing a finished game: its whole commit graph is walked and
+// every commit that playing by the rules would not have made is reported
+type AuditCommand struct {
+	db  database.Store
+	out io.Writer
+}
+
+// NewAuditCommand creates a new AuditCommand
+func NewAuditCommand(db database.Store) *AuditCommand {
+	return &AuditCommand{db: db, out: os.Stdout}
+}
+
+// Execute implements the Command interface for AuditCommand. It prints the anomalies found
+// and returns an error if there are any.
+func (c *AuditCommand) Execute(gameID string) error {
+	if gameID == "" {
+		return fmt.Errorf("audit command requires a game ID")
+	}
+
+	g, err := c.db.LoadGame()
+	if err != nil {
+		return fmt.Errorf("failed to load game: %v", err)
+	}
+	if !g.Over() {
+		return fmt.Errorf("game %s cannot be audited until it is over", gameID)
+	}
+
+	anomalies, err := c.db.Audit()
+	if err != nil {
+		return fmt.Errorf("failed to audit game: %v", err)
+	}
+	if len(anomalies) == 0 {
+		fmt.Fprintf(c.out, "No anomalies found in the history of game %s.\n", gameID)
+		return nil
+	}
+
+	w := tabwriter.NewWriter(c.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "BRANCH\tCOMMIT\tPROBLEM\tMESSAGE")
+	for _, a := range anomalies {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", a.Branch, a.Hash, a.Problem, a.Message)
+	}
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("failed to print audit report: %v", err)
+	}
+	return fmt.Errorf("found %d anomalies in the history of game %s", len(anomalies), gameID)
+}
@@ -0,0 +1,93 @@
+package commands
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"battleship/pkg/database"
+	"battleship/pkg/game"
+)
+
+// playAuditedGame plays a game to the end through join, with each team placing its
+// destroyer in the top row, and returns the team that moved first
+func playAuditedGame(t *testing.T, store database.Store, cheat func()) string {
+	t.Helper()
+	if err := store.Initialize(oneShipSettings(5)); err != nil {
+		t.Fatalf("Initialize() error = %v", err)
+	}
+	for _, team := range game.Teams {
+		team := team
+		placeShips := func() error {
+			return store.InsertShip(team+"_ships", "Destroyer", 0, 0, 2, game.Horizontal)
+		}
+		if err := join(store, team, placeShips); err != nil {
+			t.Fatalf("join(%s) error = %v", team, err)
+		}
+	}
+	g, err := store.LoadGame()
+	if err != nil {
+		t.Fatalf("LoadGame() error = %v", err)
+	}
+	first, second := g.FirstTeam, game.Opponent(g.FirstTeam)
+
+	fire(t, store, first, "A0")
+	fire(t, store, second, "E4")
+	cheat()
+	fire(t, store, first, "B0")
+	return first
+}
+
+func TestAuditCommand(t *testing.T) {
+	store := database.NewMemory()
+	playAuditedGame(t, store, func() {})
+
+	var out bytes.Buffer
+	audit := NewAuditCommand(store)
+	audit.out = &out
+	if err := audit.Execute("testId"); err != nil {
+		t.Fatalf("Execute() of an honest game error = %v\n%s", err, out.String())
+	}
+	if !strings.Contains(out.String(), "No anomalies found") {
+		t.Errorf("Execute() of an honest game printed:\n%s", out.String())
+	}
+}
+
+func TestAuditCommandCheat(t *testing.T) {
+	store := database.NewMemory()
+	var moved string
+	first := playAuditedGame(t, store, func() {
+		// The team about to be shot at moves its destroyer out of the way
+		g, err := store.LoadGame()
+		if err != nil {
+			t.Fatalf("LoadGame() error = %v", err)
+		}
+		moved = game.Opponent(g.Turn)
+		if err := store.ClearShips(moved + "_ships"); err != nil {
+			t.Fatalf("ClearShips() error = %v", err)
+		}
+		if err := store.InsertShip(moved+"_ships", "Destroyer", 3, 4, 2, game.Horizontal); err != nil {
+			t.Fatalf("InsertShip() error = %v", err)
+		}
+		if err := store.Commit("Move the destroyer"); err != nil {
+			t.Fatalf("Commit() error = %v", err)
+		}
+	})
+
+	// The game is not over until the moved destroyer is found
+	fire(t, store, moved, "C0")
+	fire(t, store, first, "D4")
+	fire(t, store, moved, "C1")
+	fire(t, store, first, "E4")
+
+	var out bytes.Buffer
+	audit := NewAuditCommand(store)
+	audit.out = &out
+	if err := audit.Execute("testId"); err == nil {
+		t.Fatalf("Execute() of a game with a moved fleet succeeded, want an error\n%s", out.String())
+	}
+	// Only the fleet changed, so the move shows up in the next shot rather than a commit of its own
+	if want := "ships row on " + moved + "_ships modified after the fleet was placed"; !strings.Contains(out.String(), want) {
+		t.Errorf("Execute() did not report %q:\n%s", want, out.String())
+	}
+}
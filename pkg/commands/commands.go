@@ -1,10 +1,18 @@
 package commands
 
 import (
+	"bufio"
+	"errors"
+	"flag"
 	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
 	"time"
 
 	"battleship/pkg/database"
+	"battleship/pkg/game"
 	"battleship/pkg/terminal"
 )
 
@@ -15,43 +23,48 @@ type Command interface {
 
 // StartCommand handles starting a new game
 type StartCommand struct {
-	db *database.Database
+	db       database.Store
+	settings game.Settings
 }
 
 // JoinRedCommand handles joining an existing game as the red team
 type JoinRedCommand struct {
-	db *database.Database
+	db        database.Store
+	fleetFile string // optional fleet layout file; ships are placed randomly without one
 }
 
 // JoinBlueCommand handles joining an existing game as the blue team
 type JoinBlueCommand struct {
-	db *database.Database
+	db        database.Store
+	fleetFile string // optional fleet layout file; ships are placed randomly without one
 }
 
 // WatchCommand handles watching an existing game
 type WatchCommand struct {
-	db   *database.Database
-	team string // "red" or "blue"
+	db    database.Store
+	team  string // "red" or "blue"
+	in    io.Reader
+	input <-chan string // words read from in, once the player is first asked for one
 }
 
 // NewStartCommand creates a new StartCommand
-func NewStartCommand(db *database.Database) *StartCommand {
-	return &StartCommand{db: db}
+func NewStartCommand(db database.Store, settings game.Settings) *StartCommand {
+	return &StartCommand{db: db, settings: settings}
 }
 
 // NewJoinRedCommand creates a new JoinRedCommand
-func NewJoinRedCommand(db *database.Database) *JoinRedCommand {
-	return &JoinRedCommand{db: db}
+func NewJoinRedCommand(db database.Store, fleetFile string) *JoinRedCommand {
+	return &JoinRedCommand{db: db, fleetFile: fleetFile}
 }
 
 // NewJoinBlueCommand creates a new JoinBlueCommand
-func NewJoinBlueCommand(db *database.Database) *JoinBlueCommand {
-	return &JoinBlueCommand{db: db}
+func NewJoinBlueCommand(db database.Store, fleetFile string) *JoinBlueCommand {
+	return &JoinBlueCommand{db: db, fleetFile: fleetFile}
 }
 
 // NewWatchCommand creates a new WatchCommand
-func NewWatchCommand(db *database.Database, team string) *WatchCommand {
-	return &WatchCommand{db: db, team: team}
+func NewWatchCommand(db database.Store, team string) *WatchCommand {
+	return &WatchCommand{db: db, team: team, in: os.Stdin}
 }
 
 // Execute implements the Command interface for StartCommand
@@ -61,7 +74,7 @@ func (c *StartCommand) Execute(gameID string) error {
 	}
 
 	// Initialize the database
-	if err := c.db.Initialize(); err != nil {
+	if err := c.db.Initialize(c.settings); err != nil {
 		return fmt.Errorf("failed to initialize database: %v", err)
 	}
 
@@ -76,24 +89,13 @@ func (c *JoinRedCommand) Execute(gameID string) error {
 	}
 	fmt.Printf("Joining game with ID: %s as Red team\n", gameID)
 
-	// Insert random number for red team
-	if err := c.db.InsertCoin("red"); err != nil {
-		return fmt.Errorf("failed to insert coin: %v", err)
-	}
-
-	// Place random ships for red team
-	if err := c.db.PlaceRandomShips("red"); err != nil {
-		return fmt.Errorf("failed to place red ships: %v", err)
-	}
-	// Commit the changes to the database with a message indicating the red team has joined
-	_, err := c.db.Exec("CALL DOLT_COMMIT('-a', '-m', ?)", "Red team has joined the game and placed their ships")
-	if err != nil {
-		return fmt.Errorf("failed to commit changes: %v", err)
-	}
-
-	// Use watch command to show the game state for red team
-	watchCmd := NewWatchCommand(c.db, "red")
-	return watchCmd.Execute(gameID)
+	// Place the red team's ships from the layout file, or randomly without one
+	return joinGame(c.db, gameID, "red", func() error {
+		if c.fleetFile != "" {
+			return placeLayout(c.db, "red", c.fleetFile)
+		}
+		return c.db.PlaceRandomShips("red")
+	})
 }
 
 // Execute implements the Command interface for JoinBlueCommand
@@ -103,25 +105,111 @@ func (c *JoinBlueCommand) Execute(gameID string) error {
 	}
 	fmt.Printf("Joining game with ID: %s as Blue team\n", gameID)
 
-	// Insert random number for blue team
-	if err := c.db.InsertCoin("blue"); err != nil {
+	// Place the blue team's ships from the layout file, or randomly without one
+	return joinGame(c.db, gameID, "blue", func() error {
+		if c.fleetFile != "" {
+			return placeLayout(c.db, "blue", c.fleetFile)
+		}
+		return c.db.PlaceRandomShips("blue")
+	})
+}
+
+// joinGame records a team joining the game and placing its fleet, then watches the game.
+// A team that has already joined, for instance before its client crashed, resumes the
+// game instead.
+func joinGame(db database.Store, gameID, team string, placeShips func() error) error {
+	if err := join(db, team, placeShips); err != nil {
+		return err
+	}
+
+	// Use watch command to show the game state for the team
+	watchCmd := NewWatchCommand(db, team)
+	return watchCmd.Execute(gameID)
+}
+
+// join records a team joining, places its fleet using placeShips, publishes a commitment
+// to the layout, tosses the coin if both teams are now present and commits the join. If
+// the team has already joined with its whole fleet placed, only a commitment or coin toss
+// that was interrupted is completed and any uncommitted changes are committed, on the
+// shared branch or only on the team's private one; if its fleet is incomplete, placement
+// starts again from an empty board.
+func join(db database.Store, team string, placeShips func() error) error {
+	g, err := db.LoadGameFor(team)
+	if err != nil {
+		return fmt.Errorf("failed to load game: %v", err)
+	}
+	resuming := g.Joined[team]
+
+	if resuming && len(g.Boards[team].Ships) == len(g.Settings.Fleet) {
+		fmt.Printf("%s team has already joined; resuming the game.\n", teamName(team))
+
+		// Complete a commitment or coin toss interrupted by the crash
+		message := fmt.Sprintf("%s team has resumed the game", teamName(team))
+		changed := false
+		if _, ok := g.Commitments[team]; !ok {
+			if err := db.CommitLayout(team); err != nil {
+				return fmt.Errorf("failed to commit to %s layout: %v", team, err)
+			}
+			message += " and committed to its fleet layout"
+			changed = true
+		}
+		first, err := db.TossCoin()
+		if err != nil {
+			return fmt.Errorf("failed to toss coin: %v", err)
+		}
+		if first != "" {
+			message += fmt.Sprintf("; %s team won the coin toss and moves first", first)
+			changed = true
+		}
+		// A join interrupted before its commit left its changes uncommitted
+		uncommitted, err := db.Uncommitted()
+		if err != nil {
+			return fmt.Errorf("failed to check for uncommitted changes: %v", err)
+		}
+		if !changed && !uncommitted {
+			return nil
+		}
+		return db.Commit(message)
+	}
+
+	// Record the team joining, or clear a fleet whose placement was interrupted
+	if resuming {
+		if err := db.ClearShips(fmt.Sprintf("%s_ships", team)); err != nil {
+			return fmt.Errorf("failed to clear %s ships: %v", team, err)
+		}
+	} else if err := db.InsertCoin(team); err != nil {
 		return fmt.Errorf("failed to insert coin: %v", err)
 	}
 
-	// Place random ships for blue team
-	if err := c.db.PlaceRandomShips("blue"); err != nil {
-		return fmt.Errorf("failed to place blue ships: %v", err)
+	if err := placeShips(); err != nil {
+		return fmt.Errorf("failed to place %s ships: %v", team, err)
 	}
 
-	// Commit the changes to the database with a message indicating the blue team has joined
-	_, err := c.db.Exec("CALL DOLT_COMMIT('-a', '-m', ?)", "Blue team has joined the game and placed their ships")
+	// Publish a commitment to the layout so that it cannot be changed unnoticed
+	if err := db.CommitLayout(team); err != nil {
+		return fmt.Errorf("failed to commit to %s layout: %v", team, err)
+	}
+
+	// Toss the coin if both teams are now present
+	first, err := db.TossCoin()
 	if err != nil {
-		return fmt.Errorf("failed to commit changes: %v", err)
+		return fmt.Errorf("failed to toss coin: %v", err)
 	}
 
-	// Use watch command to show the game state for blue team
-	watchCmd := NewWatchCommand(c.db, "blue")
-	return watchCmd.Execute(gameID)
+	// Commit the changes to the database with a message indicating the team has joined
+	commitMessage := fmt.Sprintf("%s team has joined the game and placed their ships", teamName(team))
+	if first != "" {
+		commitMessage += fmt.Sprintf("; %s team won the coin toss and moves first", first)
+	}
+	return db.Commit(commitMessage)
+}
+
+// teamName returns the capitalised name of a team for use at the start of a sentence
+func teamName(team string) string {
+	if team == "" {
+		return team
+	}
+	return strings.ToUpper(team[:1]) + team[1:]
 }
 
 // Execute implements the Command interface for WatchCommand
@@ -130,13 +218,17 @@ func (c *WatchCommand) Execute(gameID string) error {
 		return fmt.Errorf("watch command requires a game ID")
 	}
 
+	settings, err := c.db.GetSettings()
+	if err != nil {
+		return fmt.Errorf("failed to get game settings: %v", err)
+	}
+
 	// Track the ID of the DB
 	var previousRootID string
 
 	for {
-		// Call the `SELECT dolt_hashof_db();` function to get the root ID of the DB
-		var rootID string
-		err := c.db.QueryRow("SELECT dolt_hashof_db()").Scan(&rootID)
+		// Get the root ID of the DB, which changes whenever the game does
+		rootID, err := c.db.Version()
 		if err != nil {
 			return fmt.Errorf("failed to get root ID: %v", err)
 		}
@@ -152,218 +244,482 @@ func (c *WatchCommand) Execute(gameID string) error {
 		// Clear the terminal using a function from the terminal package
 		terminal.ClearScreen()
 
-		// Query the database for the current state of the coin table
-		coinRows, err := c.db.Query("SELECT team, flip FROM coin ORDER BY team")
+		// Determine whose turn it is from the game state
+		turn, move, err := c.db.GetTurn()
 		if err != nil {
-			return fmt.Errorf("failed to query coin table: %v", err)
-		}
-		defer coinRows.Close()
-
-		myTurn := false
-
-		// Determine if it's the specified team's turn based on the coin toss
-		if c.team != "" {
-			var redFlip, blueFlip float64
-
-			// Extract the flip values for both teams from the previously queried data
-			rowCount := 0
-
-			for coinRows.Next() {
-				rowCount++
-				var team string
-				var flip float64
-				if err := coinRows.Scan(&team, &flip); err != nil {
-					return fmt.Errorf("failed to scan coin row: %v", err)
-				}
-				if team == "red" {
-					redFlip = flip
-				} else if team == "blue" {
-					blueFlip = flip
-				}
-				fmt.Printf("Team: %s, Flip: %f\n", team, flip)
-			}
-			if rowCount != 2 {
-				fmt.Println("The game hasn't started yet.")
-				time.Sleep(500 * time.Millisecond)
-				continue
-			}
-
-			// Determine if it's the specified team's turn
-			if (c.team == "red" && redFlip >= blueFlip) || (c.team == "blue" && blueFlip > redFlip) {
-				myTurn = true
-			}
+			return fmt.Errorf("failed to get turn: %v", err)
 		}
-
-		// Query the database for the current state of the game
-		rows, err := c.db.Query("SELECT x, y, board, state FROM board_states ORDER BY board, x, y")
-		if err != nil {
-			return fmt.Errorf("failed to query board state: %v", err)
-		}
-		defer rows.Close()
-
-		// Initialize maps to hold the positions for both players
-		redShips := make(map[terminal.Coordinate]string)
-		blueShips := make(map[terminal.Coordinate]string)
-		redShots := make(map[terminal.Coordinate]string)
-		blueShots := make(map[terminal.Coordinate]string)
-
-		// Read the rows and populate the maps
-		for rows.Next() {
-			var x, y int
-			var board, state string
-			if err := rows.Scan(&x, &y, &board, &state); err != nil {
-				return fmt.Errorf("failed to scan row: %v", err)
-			}
-
-			coord := terminal.Coordinate{X: x, Y: y}
-			switch board {
-			case "red_ships":
-				redShips[coord] = state
-			case "blue_ships":
-				blueShips[coord] = state
-			case "red_shots":
-				redShots[coord] = state
-			case "blue_shots":
-				blueShots[coord] = state
-			}
+		if c.team != "" && turn == "" {
+			fmt.Println("The game hasn't started yet.")
+			time.Sleep(500 * time.Millisecond)
+			continue
 		}
+		myTurn := c.team != "" && turn == c.team
 
-		if err := rows.Err(); err != nil {
-			return fmt.Errorf("error iterating rows: %v", err)
+		// Query the database for the current state of the game as this team sees it
+		g, err := c.db.LoadGameFor(c.team)
+		if err != nil {
+			return err
 		}
 
 		// Print the current state of the game for the current team
 		term := terminal.New()
 		fmt.Printf("Current time: %s\n", time.Now().Format(time.RFC1123))
 		fmt.Printf("Database root ID: %s\n", previousRootID)
+		if turn != "" {
+			fmt.Printf("Move %d, %s team to play\n", move+1, turn)
+		}
 
-		switch c.team {
-		case "red":
-			term.PrintBoards(redShips, blueShots, redShots, "")
-		case "blue":
-			term.PrintBoards(blueShips, redShots, blueShots, "")
-		default:
-			// If no team specified, show both views
-			term.PrintBoards(redShips, blueShots, redShots, "red")
-			term.PrintBoards(blueShips, redShots, blueShots, "blue")
+		printGame(term, g, c.team)
+
+		// Stop watching once the game has been decided
+		if g.Over() {
+			term.PrintGameOver(g.Winner, g.Resigned, c.team)
+			return nil
 		}
 
-		if myTurn {
-			var input string
-			var x, y int
-			for {
-				fmt.Print("Enter coordinates (e.g. D3): ")
-				_, err := fmt.Scan(&input)
-				if err != nil {
-					fmt.Println("Invalid input. Please enter a letter (A-J) followed by a number (0-9).")
-					continue
-				}
-				if len(input) != 2 {
-					fmt.Println("Input must be exactly 2 characters (e.g. D3).")
-					continue
-				}
-
-				// Convert letter to x coordinate (A=0, B=1, etc.)
-				x = int(input[0] - 'A')
-				if x < 0 || x > 9 {
-					fmt.Println("First character must be a letter A-J.")
-					continue
-				}
-
-				// Convert number to y coordinate
-				y = int(input[1] - '0')
-				if y < 0 || y > 9 {
-					fmt.Println("Second character must be a number 0-9.")
-					continue
-				}
-				break
+		// The other team's request to take back its last move is answered before playing on
+		if c.team != "" && g.Takeback == game.Opponent(c.team) {
+			if err := c.answerTakeback(g); err != nil {
+				return err
 			}
+			continue
+		}
+		if c.team != "" && g.Takeback == c.team {
+			fmt.Println("Waiting for the other team to answer your takeback request...")
+		}
 
-			// Process the shot
-			opponent := "blue"
-			if c.team == "blue" {
-				opponent = "red"
-			}
-			err = c.db.ProcessShot(fmt.Sprintf("%s_shots", c.team), fmt.Sprintf("%s_ships", opponent), x, y)
-			if err != nil {
-				return fmt.Errorf("failed to process shot: %v", err)
+		if myTurn {
+			if err := c.takeShot(settings); err != nil {
+				return err
 			}
+		} else {
+			fmt.Println("Waiting for the other team to make a move...")
+		}
 
-			// Update the coin flip values for both teams in a single query
-			query := `
-				UPDATE coin 
-				SET flip = CASE 
-					WHEN team = ? THEN 0.1 
-					WHEN team = ? THEN 0.9 
-				END
-				WHERE team IN (?, ?)
-			`
-			_, err = c.db.Exec(query, c.team, opponent, c.team, opponent)
-			if err != nil {
-				return fmt.Errorf("failed to update coin values: %v", err)
-			}
+	}
+}
 
-			// Determine if the shot was a hit or a miss
-			var state string
-			query = `
-				SELECT state 
-				FROM board_states 
-				WHERE board = ? AND x = ? AND y = ?
-			`
-			err = c.db.QueryRow(query, fmt.Sprintf("%s_shots", c.team), x, y).Scan(&state)
+// answerTakeback asks the player whether to let the other team take back its last move
+func (c *WatchCommand) answerTakeback(g *game.Game) error {
+	prompt := fmt.Sprintf("The %s team asks to take back move %d. Accept? (y/n): ", g.Takeback, g.Move)
+	accept, err := c.confirm(prompt)
+	if err != nil {
+		return err
+	}
+
+	err = c.db.AnswerTakeback(c.team, accept)
+	if errors.Is(err, game.ErrNoTakeback) {
+		fmt.Println("The takeback request is no longer pending.")
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to answer takeback: %v", err)
+	}
+	return nil
+}
+
+// printGame prints a team's view of the game: its own fleet with the opponent's shots
+// and its own shots. With no team both views are printed, showing whatever fleets g holds.
+func printGame(term *terminal.Terminal, g *game.Game, team string) {
+	width, height := g.Settings.Width, g.Settings.Height
+	if team != "" {
+		opponent := game.Opponent(team)
+		term.PrintBoards(width, height, g.ShipCells(team), g.ShotCells(opponent), g.ShotCells(team), "")
+		return
+	}
+
+	for _, team := range game.Teams {
+		opponent := game.Opponent(team)
+		term.PrintBoards(width, height, g.ShipCells(team), g.ShotCells(opponent), g.ShotCells(team), team)
+	}
+}
+
+// takeShot prompts the player for a target until the database accepts the shot. It gives
+// up without a shot if the game changes while the player is typing, for instance when the
+// other team asks to take back its last move, so the watch loop can deal with it first.
+func (c *WatchCommand) takeShot(settings game.Settings) error {
+	for {
+		input, ok, err := c.read("Enter coordinates (e.g. D3) or :resign: ", true)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			fmt.Println()
+			return nil
+		}
+		x, y, command, err := parseTarget(input, settings.Width, settings.Height)
+		if err != nil {
+			fmt.Printf("%v.\n", err)
+			continue
+		}
+		if command == ":resign" {
+			resign, err := c.confirm("Resign the game? (y/n): ")
 			if err != nil {
-				return fmt.Errorf("failed to determine shot result: %v", err)
+				return err
+			}
+			if !resign {
+				continue
+			}
+			if err := c.db.Resign(c.team); err != nil {
+				return fmt.Errorf("failed to resign: %v", err)
 			}
+			return nil
+		}
 
-			// Create a detailed commit message
-			commitMessage := fmt.Sprintf("Team %s shot at (%c%d) and it was a %s", c.team, 'A'+x, y, map[string]string{"H": "hit", "M": "miss"}[state])
+		// A takeback requested just as the player entered the shot is answered first
+		g, err := c.db.LoadGame()
+		if err != nil {
+			return err
+		}
+		if g.Takeback == game.Opponent(c.team) {
+			fmt.Printf("The %s team has asked to take back their last move.\n", g.Takeback)
+			return nil
+		}
 
-			// Commit the changes to the database with the detailed message
-			_, err = c.db.Exec("CALL DOLT_COMMIT('-a', '-m', ?)", commitMessage)
+		shot, err := c.db.FireShot(c.team, x, y)
+		if errors.Is(err, game.ErrAlreadyShot) {
+			fmt.Printf("You have already shot at (%c%d). Choose another target.\n", 'A'+x, y)
+			continue
+		}
+		if errors.Is(err, game.ErrOffBoard) {
+			fmt.Printf("(%c%d) is not on the board. Choose another target.\n", 'A'+x, y)
+			continue
+		}
+		if errors.Is(err, game.ErrNotYourTurn) || errors.Is(err, game.ErrGameOver) {
+			// The game moved on while we were waiting for input, so go back to watching
+			fmt.Printf("Shot rejected: %v.\n", err)
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to fire shot: %v", err)
+		}
+
+		fmt.Printf("Shot at (%s) was a %s!\n", shot.Target, shot.Outcome())
+		return nil
+	}
+}
+
+// confirm asks the player a yes/no question until it is answered
+func (c *WatchCommand) confirm(prompt string) (bool, error) {
+	for {
+		input, _, err := c.read(prompt, false)
+		if err != nil {
+			return false, err
+		}
+		if answer, ok := parseYesNo(input); ok {
+			return answer, nil
+		}
+	}
+}
+
+// read prints a prompt and returns the next word the player enters. Input is read in the
+// background, so with watch set the game is checked while waiting and read returns false
+// as soon as it changes; anything the player goes on to type is kept for the next prompt.
+func (c *WatchCommand) read(prompt string, watch bool) (string, bool, error) {
+	if c.input == nil {
+		c.input = scanWords(c.in)
+	}
+	version, err := c.db.Version()
+	if err != nil {
+		return "", false, fmt.Errorf("failed to get root ID: %v", err)
+	}
+
+	fmt.Print(prompt)
+	ticker := time.NewTicker(500 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		select {
+		case word, ok := <-c.input:
+			if !ok {
+				return "", false, fmt.Errorf("no more input to read")
+			}
+			return word, true, nil
+		case <-ticker.C:
+			if !watch {
+				continue
+			}
+			now, err := c.db.Version()
 			if err != nil {
-				return fmt.Errorf("failed to commit changes: %v", err)
+				return "", false, fmt.Errorf("failed to get root ID: %v", err)
 			}
+			if now != version {
+				return "", false, nil
+			}
+		}
+	}
+}
 
-			fmt.Println("Shot processed successfully!")
-		} else {
-			fmt.Println("Waiting for the other team to make a move...")
+// scanWords sends each word read from r, as fmt.Scan would read them, until r runs out
+func scanWords(r io.Reader) <-chan string {
+	words := make(chan string)
+	go func() {
+		defer close(words)
+		scanner := bufio.NewScanner(r)
+		scanner.Split(bufio.ScanWords)
+		for scanner.Scan() {
+			words <- scanner.Text()
 		}
+	}()
+	return words
+}
 
+// promptCoordinates reads coordinates such as D3 from standard input until a pair on a
+// board of the given size is entered
+func promptCoordinates(prompt string, width, height int) (int, int) {
+	var input string
+	for {
+		fmt.Print(prompt)
+		_, err := fmt.Scan(&input)
+		if err != nil {
+			fmt.Printf("Invalid input. Please enter a letter (A-%c) followed by a number (0-%d).\n", 'A'+width-1, height-1)
+			continue
+		}
+
+		x, y, err := parseCoordinates(input, width, height)
+		if err != nil {
+			fmt.Printf("%v.\n", err)
+			continue
+		}
+		return x, y
 	}
+}
 
-	return nil
+// parseTarget reads either coordinates such as D3 or an in-game command such as :resign
+// entered at the shot prompt. The command is returned without coordinates if one was
+// entered.
+func parseTarget(input string, width, height int) (int, int, string, error) {
+	if strings.HasPrefix(input, ":") {
+		switch command := strings.ToLower(input); command {
+		case ":resign":
+			return 0, 0, command, nil
+		default:
+			return 0, 0, "", fmt.Errorf("unknown command %s; the only command is :resign", input)
+		}
+	}
+
+	x, y, err := parseCoordinates(input, width, height)
+	return x, y, "", err
+}
+
+// parseCoordinates converts coordinates such as D3 or K12 into x and y positions on a
+// board of the given size
+func parseCoordinates(input string, width, height int) (int, int, error) {
+	if len(input) < 2 {
+		return 0, 0, fmt.Errorf("input must be a letter followed by a number (e.g. D3)")
+	}
+
+	// Convert letter to x coordinate (A=0, B=1, etc.)
+	x := int(input[0]) - 'A'
+	if x < 0 || x >= width {
+		return 0, 0, fmt.Errorf("first character must be a letter A-%c", 'A'+width-1)
+	}
+
+	// Convert the remaining digits to y coordinate
+	digits := input[1:]
+	y, err := strconv.Atoi(digits)
+	if err != nil || strings.TrimLeft(digits, "0123456789") != "" || y >= height {
+		return 0, 0, fmt.Errorf("letter must be followed by a number 0-%d", height-1)
+	}
+
+	return x, y, nil
 }
 
-// RunCommand executes the appropriate command based on arguments
+// RunCommand executes the appropriate command based on arguments. Connection options
+// may come before the command, e.g. battleship --addr dolt:3306 start 42.
 func RunCommand(args []string) error {
-	if len(args) < 2 {
-		return fmt.Errorf("usage: battleship <command> <gameID>\ncommands: start, join-red, join-blue, watch")
+	config, args, err := parseConnection(args)
+	if err != nil {
+		return err
+	}
+	return Run(args, database.NewDoltServer(config))
+}
+
+// parseConnection reads the Dolt connection settings from the options before the
+// command and returns the remaining arguments. Settings are taken from, in order of
+// precedence, the --user, --password, --addr and --database options, the --dsn option,
+// the BATTLESHIP_DSN environment variable, the config file and the defaults.
+func parseConnection(args []string) (database.Config, []string, error) {
+	config := database.DefaultConfig()
+
+	flags := flag.NewFlagSet("battleship", flag.ContinueOnError)
+	configFile := flags.String("config", os.Getenv("BATTLESHIP_CONFIG"), "connection config file (default "+database.DefaultConfigFile()+")")
+	dsn := flags.String("dsn", os.Getenv("BATTLESHIP_DSN"), "MySQL data source name of the Dolt sql-server, e.g. root:@tcp(localhost:9889)/battleship")
+	user := flags.String("user", "", "user to connect as")
+	password := flags.String("password", "", "password to connect with")
+	addr := flags.String("addr", "", "host:port of the Dolt sql-server")
+	name := flags.String("database", "", "database holding the games")
+	if err := flags.Parse(args[1:]); err != nil {
+		return config, nil, err
+	}
+
+	// The default config file is optional; one that is asked for must exist
+	if *configFile != "" {
+		if err := config.LoadFile(*configFile); err != nil {
+			return config, nil, err
+		}
+	} else if path := database.DefaultConfigFile(); path != "" {
+		if _, err := os.Stat(path); err == nil {
+			if err := config.LoadFile(path); err != nil {
+				return config, nil, err
+			}
+		}
+	}
+
+	if *dsn != "" {
+		if err := config.ParseDSN(*dsn); err != nil {
+			return config, nil, err
+		}
+	}
+
+	// Individual options override everything else
+	flags.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "user":
+			config.User = *user
+		case "password":
+			config.Password = *password
+		case "addr":
+			config.Addr = *addr
+		case "database":
+			config.Database = *name
+		}
+	})
+
+	return config, append([]string{args[0]}, flags.Args()...), nil
+}
+
+// Run executes the command named in args against a game held by the given server
+func Run(args []string, server database.Server) error {
+	// Listing games is the only command that does not work on a single game
+	if len(args) == 2 && args[1] == "list" {
+		return NewListCommand(server).Execute("")
+	}
+	if len(args) < 3 {
+		return fmt.Errorf("usage: battleship [connection options] <command> <gameID> [arguments]\ncommands: start, join-red, join-blue, place, watch, undo, resign, replay, history, verify, referee, audit, list")
 	}
 
 	command := args[1]
 	gameID := args[2]
 
-	// Initialize database connection
-	db, err := database.New(gameID)
-	if err != nil {
-		return fmt.Errorf("failed to initialize database: %v", err)
+	// Every command but start works on an existing game
+	open := func() (database.Store, error) {
+		return server.Open(gameID)
 	}
-	defer db.Close()
 
-	var cmd Command
+	var newCommand func(db database.Store) Command
 	switch command {
 	case "start":
-		cmd = NewStartCommand(db)
-	case "join-red":
-		cmd = NewJoinRedCommand(db)
-	case "join-blue":
-		cmd = NewJoinBlueCommand(db)
+		defaults := game.DefaultSettings()
+		flags := flag.NewFlagSet(command, flag.ContinueOnError)
+		ships := flags.String("ships", "classic", "fleet to play with: classic, hasbro, russian or a list of ship lengths such as 5,4,3,3,2")
+		width := flags.Int("width", defaults.Width, "number of columns on the board")
+		height := flags.Int("height", defaults.Height, "number of rows on the board")
+		force := flags.Bool("force", false, "replace an existing game with the same ID")
+		if err := flags.Parse(args[3:]); err != nil {
+			return err
+		}
+		fleet, err := game.ParseFleet(*ships)
+		if err != nil {
+			return err
+		}
+		settings := game.Settings{Fleet: fleet, Width: *width, Height: *height}
+		if err := settings.Validate(); err != nil {
+			return fmt.Errorf("invalid game settings: %v", err)
+		}
+		open = func() (database.Store, error) {
+			return server.Create(gameID, *force)
+		}
+		newCommand = func(db database.Store) Command {
+			return NewStartCommand(db, settings)
+		}
+	case "join-red", "join-blue":
+		flags := flag.NewFlagSet(command, flag.ContinueOnError)
+		fleetFile := flags.String("fleet", "", "fleet layout file to place ships from")
+		if err := flags.Parse(args[3:]); err != nil {
+			return err
+		}
+		newCommand = func(db database.Store) Command {
+			if command == "join-red" {
+				return NewJoinRedCommand(db, *fleetFile)
+			}
+			return NewJoinBlueCommand(db, *fleetFile)
+		}
+	case "place":
+		// The team to place ships for follows the game ID
+		if len(args) < 4 {
+			return fmt.Errorf("usage: battleship place <gameID> <red|blue>")
+		}
+		newCommand = func(db database.Store) Command {
+			return NewPlaceCommand(db, args[3])
+		}
+	case "replay":
+		flags := flag.NewFlagSet(command, flag.ContinueOnError)
+		speed := flags.Duration("speed", 0, "play the game automatically with this delay between commits, e.g. 1s")
+		if err := flags.Parse(args[3:]); err != nil {
+			return err
+		}
+		newCommand = func(db database.Store) Command {
+			return NewReplayCommand(db, *speed)
+		}
+	case "audit":
+		newCommand = func(db database.Store) Command {
+			return NewAuditCommand(db)
+		}
+	case "history":
+		flags := flag.NewFlagSet(command, flag.ContinueOnError)
+		asJSON := flags.Bool("json", false, "print the moves as JSON")
+		if err := flags.Parse(args[3:]); err != nil {
+			return err
+		}
+		newCommand = func(db database.Store) Command {
+			return NewHistoryCommand(db, *asJSON)
+		}
+	case "referee":
+		flags := flag.NewFlagSet(command, flag.ContinueOnError)
+		revert := flags.Bool("revert", false, "revert illegal commits as well as flagging them")
+		interval := flags.Duration("interval", time.Second, "how often to check for new commits")
+		if err := flags.Parse(args[3:]); err != nil {
+			return err
+		}
+		newCommand = func(db database.Store) Command {
+			return NewRefereeCommand(db, *revert, *interval)
+		}
+	case "verify":
+		newCommand = func(db database.Store) Command {
+			return NewVerifyCommand(db)
+		}
+	case "resign":
+		// The team resigning follows the game ID
+		if len(args) < 4 {
+			return fmt.Errorf("usage: battleship resign <gameID> <red|blue>")
+		}
+		newCommand = func(db database.Store) Command {
+			return NewResignCommand(db, args[3])
+		}
+	case "undo":
+		// The team asking for the takeback follows the game ID
+		if len(args) < 4 {
+			return fmt.Errorf("usage: battleship undo <gameID> <red|blue>")
+		}
+		newCommand = func(db database.Store) Command {
+			return NewUndoCommand(db, args[3])
+		}
 	case "watch":
 		// For watch command, we'll show both views
-		cmd = NewWatchCommand(db, "")
+		newCommand = func(db database.Store) Command {
+			return NewWatchCommand(db, "")
+		}
 	default:
-		return fmt.Errorf("unknown command: %s\navailable commands: start, join-red, join-blue, watch", command)
+		return fmt.Errorf("unknown command: %s\navailable commands: start, join-red, join-blue, place, watch, undo, resign, replay, history, verify, referee, audit, list", command)
+	}
+
+	// Errors from opening the game already say what went wrong
+	db, err := open()
+	if err != nil {
+		return err
 	}
+	defer db.Close()
 
-	return cmd.Execute(gameID)
+	return newCommand(db).Execute(gameID)
 }
@@ -0,0 +1,278 @@
+package commands
+
+import (
+	"errors"
+	"testing"
+
+	"battleship/pkg/database"
+	"battleship/pkg/game"
+)
+
+// oneShipSettings returns the settings of a square test game whose fleet is a single destroyer
+func oneShipSettings(size int) game.Settings {
+	return game.Settings{Fleet: game.Fleet{{Name: "Destroyer", Length: 2}}, Width: size, Height: size}
+}
+
+// startTestGame starts a game on a 5x5 board with one destroyer at A0-B0 for each
+// team, joins both teams with their layouts committed and returns the team that won
+// the coin toss
+func startTestGame(t *testing.T, store database.Store) string {
+	t.Helper()
+	if err := store.Initialize(oneShipSettings(5)); err != nil {
+		t.Fatalf("Initialize() error = %v", err)
+	}
+	for _, team := range game.Teams {
+		if err := store.InsertCoin(team); err != nil {
+			t.Fatalf("InsertCoin(%s) error = %v", team, err)
+		}
+		if err := store.InsertShip(team+"_ships", "Destroyer", 0, 0, 2, game.Horizontal); err != nil {
+			t.Fatalf("InsertShip(%s) error = %v", team, err)
+		}
+		if err := store.CommitLayout(team); err != nil {
+			t.Fatalf("CommitLayout(%s) error = %v", team, err)
+		}
+	}
+	first, err := store.TossCoin()
+	if err != nil {
+		t.Fatalf("TossCoin() error = %v", err)
+	}
+	if err := store.Commit("Both teams have joined"); err != nil {
+		t.Fatalf("Commit() error = %v", err)
+	}
+	return first
+}
+
+// fire makes a move in a test game
+func fire(t *testing.T, store database.Store, team string, target string) {
+	t.Helper()
+	x, y, err := parseCoordinates(target, 5, 5)
+	if err != nil {
+		t.Fatalf("parseCoordinates(%q) error = %v", target, err)
+	}
+	if _, err := store.FireShot(team, x, y); err != nil {
+		t.Fatalf("FireShot(%s, %s) error = %v", team, target, err)
+	}
+}
+
+func TestParseCoordinates(t *testing.T) {
+	tests := []struct {
+		input         string
+		width, height int
+		x, y          int
+		wantErr       bool
+	}{
+		{input: "A0", width: 10, height: 10, x: 0, y: 0},
+		{input: "J9", width: 10, height: 10, x: 9, y: 9},
+		{input: "D3", width: 10, height: 10, x: 3, y: 3},
+		{input: "K12", width: 15, height: 15, x: 10, y: 12},
+		{input: "Z25", width: 26, height: 26, x: 25, y: 25},
+		{input: "K0", width: 10, height: 10, wantErr: true},
+		{input: "A10", width: 10, height: 10, wantErr: true},
+		{input: "a1", width: 10, height: 10, wantErr: true},
+		{input: "A", width: 10, height: 10, wantErr: true},
+		{input: "A-1", width: 10, height: 10, wantErr: true},
+		{input: "A+1", width: 10, height: 10, wantErr: true},
+		{input: "AB", width: 10, height: 10, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			x, y, err := parseCoordinates(tt.input, tt.width, tt.height)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseCoordinates(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if !tt.wantErr && (x != tt.x || y != tt.y) {
+				t.Errorf("parseCoordinates(%q) = (%d, %d), want (%d, %d)", tt.input, x, y, tt.x, tt.y)
+			}
+		})
+	}
+}
+
+func TestRunStart(t *testing.T) {
+	server := database.NewMemoryServer()
+	args := []string{"battleship", "start", "testId", "--ships", "russian", "--width", "12", "--height", "8"}
+	if err := Run(args, server); err != nil {
+		t.Fatalf("Run(start) error = %v", err)
+	}
+
+	store, err := server.Open("testId")
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	settings, err := store.GetSettings()
+	if err != nil {
+		t.Fatalf("GetSettings() error = %v", err)
+	}
+	if settings.Width != 12 || settings.Height != 8 || len(settings.Fleet) != len(game.Fleets["russian"]) {
+		t.Errorf("GetSettings() = %+v, want a 12x8 board with the russian fleet", settings)
+	}
+
+	// Starting the same game again needs --force
+	if err := Run([]string{"battleship", "start", "testId"}, server); !errors.Is(err, database.ErrGameExists) {
+		t.Errorf("Run(start) of an existing game error = %v, want %v", err, database.ErrGameExists)
+	}
+	if err := Run([]string{"battleship", "start", "testId", "--force"}, server); err != nil {
+		t.Fatalf("Run(start --force) error = %v", err)
+	}
+	if replaced, _ := server.Open("testId"); replaced == store {
+		t.Error("Run(start --force) kept the old game")
+	}
+
+	if err := Run([]string{"battleship", "start", "other", "--width", "3"}, server); err == nil {
+		t.Error("Run(start) with a 3 column board succeeded, want an error")
+	}
+	if _, err := server.Open("other"); !errors.Is(err, database.ErrGameNotFound) {
+		t.Errorf("Open() of a game with invalid settings error = %v, want %v", err, database.ErrGameNotFound)
+	}
+	if err := Run([]string{"battleship", "watch", "missing"}, server); !errors.Is(err, database.ErrGameNotFound) {
+		t.Errorf("Run(watch) of a missing game error = %v, want %v", err, database.ErrGameNotFound)
+	}
+	if err := Run([]string{"battleship", "fly", "testId"}, server); err == nil {
+		t.Error("Run(fly) succeeded, want an unknown command error")
+	}
+}
+
+func TestParseConnection(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("BATTLESHIP_CONFIG", "")
+	t.Setenv("BATTLESHIP_DSN", "alice:secret@tcp(dolt.example.com:3306)/games")
+
+	args := []string{"battleship", "--addr", "localhost:3307", "start", "42", "--width", "12"}
+	config, rest, err := parseConnection(args)
+	if err != nil {
+		t.Fatalf("parseConnection() error = %v", err)
+	}
+
+	want := database.Config{User: "alice", Password: "secret", Addr: "localhost:3307", Database: "games"}
+	if config != want {
+		t.Errorf("parseConnection() config = %+v, want %+v", config, want)
+	}
+	if len(rest) != 5 || rest[0] != "battleship" || rest[1] != "start" || rest[2] != "42" {
+		t.Errorf("parseConnection() args = %q, want the command and its arguments", rest)
+	}
+
+	t.Setenv("BATTLESHIP_DSN", "")
+	config, _, err = parseConnection([]string{"battleship", "watch", "42"})
+	if err != nil {
+		t.Fatalf("parseConnection() error = %v", err)
+	}
+	if config != database.DefaultConfig() {
+		t.Errorf("parseConnection() with no options = %+v, want the defaults", config)
+	}
+}
+
+func TestJoinResume(t *testing.T) {
+	store := database.NewMemory()
+	if err := store.Initialize(oneShipSettings(5)); err != nil {
+		t.Fatalf("Initialize() error = %v", err)
+	}
+
+	placed := 0
+	placeShips := func(team string) func() error {
+		return func() error {
+			placed++
+			return store.InsertShip(team+"_ships", "Destroyer", 0, 0, 2, game.Horizontal)
+		}
+	}
+
+	if err := join(store, game.Red, placeShips(game.Red)); err != nil {
+		t.Fatalf("join(red) error = %v", err)
+	}
+	// Joining again resumes without placing the fleet a second time
+	if err := join(store, game.Red, placeShips(game.Red)); err != nil {
+		t.Fatalf("join(red) again error = %v", err)
+	}
+	if placed != 1 {
+		t.Errorf("fleet placed %d times, want 1", placed)
+	}
+
+	// A join interrupted during placement starts the placement again
+	if err := store.InsertCoin(game.Blue); err != nil {
+		t.Fatalf("InsertCoin(blue) error = %v", err)
+	}
+	if err := join(store, game.Blue, placeShips(game.Blue)); err != nil {
+		t.Fatalf("join(blue) after an interrupted placement error = %v", err)
+	}
+	g, err := store.LoadGameFor(game.Blue)
+	if err != nil {
+		t.Fatalf("LoadGameFor(blue) error = %v", err)
+	}
+	if len(g.Boards[game.Blue].Ships) != 1 || !game.ValidTeam(g.FirstTeam) {
+		t.Errorf("after resuming blue ships = %v, first team = %q, want one ship and a coin toss", g.Boards[game.Blue].Ships, g.FirstTeam)
+	}
+}
+
+func TestJoinResumeUncommitted(t *testing.T) {
+	store := database.NewMemory()
+	if err := store.Initialize(oneShipSettings(5)); err != nil {
+		t.Fatalf("Initialize() error = %v", err)
+	}
+
+	// Red's client crashes after everything but the commit of its join
+	if err := store.InsertCoin(game.Red); err != nil {
+		t.Fatalf("InsertCoin(red) error = %v", err)
+	}
+	if err := store.InsertShip("red_ships", "Destroyer", 0, 0, 2, game.Horizontal); err != nil {
+		t.Fatalf("InsertShip(red) error = %v", err)
+	}
+	if err := store.CommitLayout(game.Red); err != nil {
+		t.Fatalf("CommitLayout(red) error = %v", err)
+	}
+
+	placeShips := func() error {
+		t.Error("placeShips called when resuming with a complete fleet")
+		return nil
+	}
+	if err := join(store, game.Red, placeShips); err != nil {
+		t.Fatalf("join(red) error = %v", err)
+	}
+
+	if uncommitted, err := store.Uncommitted(); err != nil || uncommitted {
+		t.Errorf("Uncommitted() after resuming = %v, %v, want false", uncommitted, err)
+	}
+	history, err := store.History()
+	if err != nil {
+		t.Fatalf("History() error = %v", err)
+	}
+	if last := history[len(history)-1].Message; last != "Red team has resumed the game" {
+		t.Errorf("last commit = %q, want the resumed join", last)
+	}
+}
+
+func TestJoinResumePrivateOnly(t *testing.T) {
+	store := database.NewMemory()
+	if err := store.Initialize(oneShipSettings(5)); err != nil {
+		t.Fatalf("Initialize() error = %v", err)
+	}
+	if err := join(store, game.Red, func() error {
+		return store.InsertShip("red_ships", "Destroyer", 0, 0, 2, game.Horizontal)
+	}); err != nil {
+		t.Fatalf("join(red) error = %v", err)
+	}
+	before, err := store.History()
+	if err != nil {
+		t.Fatalf("History() error = %v", err)
+	}
+
+	// The join is committed on the shared branch but red's fleet is left uncommitted
+	if err := store.ClearShips("red_ships"); err != nil {
+		t.Fatalf("ClearShips(red) error = %v", err)
+	}
+	if err := store.InsertShip("red_ships", "Destroyer", 0, 0, 2, game.Horizontal); err != nil {
+		t.Fatalf("InsertShip(red) error = %v", err)
+	}
+
+	placeShips := func() error {
+		t.Error("placeShips called when resuming with a complete fleet")
+		return nil
+	}
+	if err := join(store, game.Red, placeShips); err != nil {
+		t.Fatalf("join(red) with only the fleet uncommitted error = %v", err)
+	}
+	if uncommitted, err := store.Uncommitted(); err != nil || uncommitted {
+		t.Errorf("Uncommitted() after resuming = %v, %v, want false", uncommitted, err)
+	}
+	if after, _ := store.History(); len(after) != len(before) {
+		t.Errorf("shared history has %d commits after resuming, want %d", len(after), len(before))
+	}
+}
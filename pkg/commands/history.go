@@ -0,0 +1,87 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"battleship/pkg/database"
+)
+
+// HistoryCommand handles listing the moves of a game
+type HistoryCommand struct {
+	db     database.Store
+	asJSON bool // print the moves as a JSON array instead of a table
+	out    io.Writer
+}
+
+// moveRecord is a move as printed by HistoryCommand with --json
+type moveRecord struct {
+	Move   int       `json:"move"`
+	Team   string    `json:"team"`
+	Target string    `json:"target"`
+	Result string    `json:"result"`
+	Sunk   string    `json:"sunk,omitempty"`
+	Winner string    `json:"winner,omitempty"`
+	Time   time.Time `json:"time"`
+	Commit string    `json:"commit"`
+}
+
+// NewHistoryCommand creates a new HistoryCommand
+func NewHistoryCommand(db database.Store, asJSON bool) *HistoryCommand {
+	return &HistoryCommand{db: db, asJSON: asJSON, out: os.Stdout}
+}
+
+// Execute implements the Command interface for HistoryCommand
+func (c *HistoryCommand) Execute(gameID string) error {
+	if gameID == "" {
+		return fmt.Errorf("history command requires a game ID")
+	}
+
+	moves, err := c.db.Moves()
+	if err != nil {
+		return fmt.Errorf("failed to read game history: %v", err)
+	}
+
+	records := make([]moveRecord, 0, len(moves))
+	for _, move := range moves {
+		result := "miss"
+		switch {
+		case move.Sunk != "":
+			result = "sunk"
+		case move.Hit:
+			result = "hit"
+		}
+		records = append(records, moveRecord{
+			Move:   move.Move,
+			Team:   move.Team,
+			Target: move.Target.String(),
+			Result: result,
+			Sunk:   move.Sunk,
+			Winner: move.Winner,
+			Time:   move.Date,
+			Commit: move.Hash,
+		})
+	}
+
+	if c.asJSON {
+		encoder := json.NewEncoder(c.out)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(records)
+	}
+
+	if len(moves) == 0 {
+		fmt.Fprintln(c.out, "No moves have been made.")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(c.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "MOVE\tTEAM\tTARGET\tRESULT\tTIME\tCOMMIT")
+	for _, move := range moves {
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\n", move.Move, move.Team, move.Target, move.Outcome(), move.Date.Format("2006-01-02 15:04:05"), move.Hash)
+	}
+	return w.Flush()
+}
@@ -0,0 +1,62 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"battleship/pkg/database"
+	"battleship/pkg/game"
+)
+
+func TestHistoryCommand(t *testing.T) {
+	store := database.NewMemory()
+	first := startTestGame(t, store)
+	second := game.Opponent(first)
+	fire(t, store, first, "C3")
+	fire(t, store, second, "A0")
+	fire(t, store, first, "A0")
+	fire(t, store, second, "D4")
+	fire(t, store, first, "B0")
+
+	var out bytes.Buffer
+	history := NewHistoryCommand(store, true)
+	history.out = &out
+	if err := history.Execute("testId"); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+
+	var records []moveRecord
+	if err := json.Unmarshal(out.Bytes(), &records); err != nil {
+		t.Fatalf("Execute() printed invalid JSON: %v\n%s", err, out.String())
+	}
+	want := []moveRecord{
+		{Move: 1, Team: first, Target: "C3", Result: "miss"},
+		{Move: 2, Team: second, Target: "A0", Result: "hit"},
+		{Move: 3, Team: first, Target: "A0", Result: "hit"},
+		{Move: 4, Team: second, Target: "D4", Result: "miss"},
+		{Move: 5, Team: first, Target: "B0", Result: "sunk", Sunk: "Destroyer", Winner: first},
+	}
+	if len(records) != len(want) {
+		t.Fatalf("Execute() printed %d moves, want %d", len(records), len(want))
+	}
+	for i, record := range records {
+		if record.Commit == "" || record.Time.IsZero() {
+			t.Errorf("move %d has no commit or time: %+v", i+1, record)
+		}
+		record.Commit, record.Time = "", want[i].Time
+		if record != want[i] {
+			t.Errorf("move %d = %+v, want %+v", i+1, record, want[i])
+		}
+	}
+
+	out.Reset()
+	history.asJSON = false
+	if err := history.Execute("testId"); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if lines := strings.Split(strings.TrimSpace(out.String()), "\n"); len(lines) != 6 || !strings.Contains(lines[5], "hit and sunk Destroyer") {
+		t.Errorf("Execute() printed:\n%s\nwant a header and 5 moves ending with the sinking", out.String())
+	}
+}
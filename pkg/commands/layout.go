@@ -0,0 +1,114 @@
+package commands
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"battleship/pkg/database"
+	"battleship/pkg/game"
+)
+
+// shipPlacement is the position of one ship read from a fleet layout file
+type shipPlacement struct {
+	ship      game.Ship
+	x, y      int
+	direction game.Direction
+}
+
+// readLayout reads a fleet layout file. Each non-empty line names a ship, its bow
+// coordinate and its direction, e.g. "Carrier D3 h" or "Patrol Boat A0 v"; lines
+// starting with # are ignored.
+// The layout must place every ship of the game's fleet exactly once.
+func readLayout(path string, settings game.Settings) ([]shipPlacement, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open fleet layout: %v", err)
+	}
+	defer file.Close()
+
+	ships := make(map[string]game.Ship)
+	for _, ship := range settings.Fleet {
+		ships[strings.ToLower(ship.Name)] = ship
+	}
+
+	var placements []shipPlacement
+	placed := make(map[string]bool)
+	scanner := bufio.NewScanner(file)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		// The coordinate and direction are the last two fields; ship names may contain spaces
+		fields := strings.Fields(line)
+		if len(fields) < 3 {
+			return nil, fmt.Errorf("%s:%d: expected <ship> <coordinate> <h|v>", path, lineNumber)
+		}
+		name := strings.Join(fields[:len(fields)-2], " ")
+		coordinate, orientation := fields[len(fields)-2], fields[len(fields)-1]
+
+		// Ship names are matched against the fleet case-insensitively
+		ship, ok := ships[strings.ToLower(name)]
+		if !ok {
+			return nil, fmt.Errorf("%s:%d: unknown ship %q", path, lineNumber, name)
+		}
+		if placed[ship.Name] {
+			return nil, fmt.Errorf("%s:%d: %s is placed more than once", path, lineNumber, ship.Name)
+		}
+		placed[ship.Name] = true
+
+		x, y, err := parseCoordinates(strings.ToUpper(coordinate), settings.Width, settings.Height)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %v", path, lineNumber, err)
+		}
+
+		var direction game.Direction
+		switch strings.ToLower(orientation) {
+		case "h", "horizontal":
+			direction = game.Horizontal
+		case "v", "vertical":
+			direction = game.Vertical
+		default:
+			return nil, fmt.Errorf("%s:%d: direction must be h or v", path, lineNumber)
+		}
+
+		placements = append(placements, shipPlacement{ship: ship, x: x, y: y, direction: direction})
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read fleet layout: %v", err)
+	}
+
+	for _, ship := range settings.Fleet {
+		if !placed[ship.Name] {
+			return nil, fmt.Errorf("%s: %s is missing from the layout", path, ship.Name)
+		}
+	}
+
+	return placements, nil
+}
+
+// placeLayout inserts the ships of a fleet layout file onto a team's board
+func placeLayout(db database.Store, team, path string) error {
+	settings, err := db.GetSettings()
+	if err != nil {
+		return err
+	}
+
+	placements, err := readLayout(path, settings)
+	if err != nil {
+		return err
+	}
+
+	board := fmt.Sprintf("%s_ships", team)
+	for _, p := range placements {
+		if err := db.InsertShip(board, p.ship.Name, p.x, p.y, p.ship.Length, p.direction); err != nil {
+			return fmt.Errorf("failed to place %s from %s: %v", p.ship.Name, path, err)
+		}
+	}
+
+	return nil
+}
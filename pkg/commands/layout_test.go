@@ -0,0 +1,106 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"battleship/pkg/database"
+	"battleship/pkg/game"
+)
+
+func writeLayout(t *testing.T, contents string) string {
+	path := filepath.Join(t.TempDir(), "layout.txt")
+	if err := os.WriteFile(path, []byte(contents), 0o644); err != nil {
+		t.Fatalf("Failed to write layout: %v", err)
+	}
+	return path
+}
+
+func TestReadLayout(t *testing.T) {
+	path := writeLayout(t, `# A complete classic fleet
+Carrier A0 h
+battleship B2 v
+Cruiser D3 horizontal
+
+Submarine F6 V
+Destroyer J0 vertical
+`)
+
+	placements, err := readLayout(path, game.DefaultSettings())
+	if err != nil {
+		t.Fatalf("readLayout() error = %v", err)
+	}
+	if len(placements) != 5 {
+		t.Fatalf("readLayout() returned %d placements, want 5", len(placements))
+	}
+
+	battleship := placements[1]
+	if battleship.ship.Name != "Battleship" || battleship.x != 1 || battleship.y != 2 || battleship.direction != game.Vertical {
+		t.Errorf("readLayout() battleship = %+v, want Battleship at (1,2) vertical", battleship)
+	}
+}
+
+func TestReadLayoutErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		contents string
+	}{
+		{
+			name:     "Missing ship",
+			contents: "Carrier A0 h\nBattleship A1 h\nCruiser A2 h\nSubmarine A3 h\n",
+		},
+		{
+			name:     "Duplicate ship",
+			contents: "Carrier A0 h\nCarrier A1 h\nBattleship A2 h\nCruiser A3 h\nSubmarine A4 h\nDestroyer A5 h\n",
+		},
+		{
+			name:     "Unknown ship",
+			contents: "Carrier A0 h\nBattleship A1 h\nCruiser A2 h\nSubmarine A3 h\nDestroyer A4 h\nRowboat A5 h\n",
+		},
+		{
+			name:     "Bad coordinate",
+			contents: "Carrier Z0 h\nBattleship A1 h\nCruiser A2 h\nSubmarine A3 h\nDestroyer A4 h\n",
+		},
+		{
+			name:     "Bad direction",
+			contents: "Carrier A0 diagonal\nBattleship A1 h\nCruiser A2 h\nSubmarine A3 h\nDestroyer A4 h\n",
+		},
+		{
+			name:     "Missing field",
+			contents: "Carrier A0\nBattleship A1 h\nCruiser A2 h\nSubmarine A3 h\nDestroyer A4 h\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := readLayout(writeLayout(t, tt.contents), game.DefaultSettings()); err == nil {
+				t.Error("readLayout() error = nil, want an error")
+			}
+		})
+	}
+}
+
+func TestPlaceLayout(t *testing.T) {
+	path := writeLayout(t, "Carrier A0 h\nBattleship B2 v\nCruiser D3 h\nSubmarine F6 v\nDestroyer J0 v\n")
+
+	store := database.NewMemory()
+	if err := store.Initialize(game.DefaultSettings()); err != nil {
+		t.Fatalf("Initialize() error = %v", err)
+	}
+	if err := placeLayout(store, game.Blue, path); err != nil {
+		t.Fatalf("placeLayout() error = %v", err)
+	}
+
+	g, err := store.LoadGameFor(game.Blue)
+	if err != nil {
+		t.Fatalf("LoadGameFor(blue) error = %v", err)
+	}
+	ship, ok := g.Boards[game.Blue].ShipAt(game.Coordinate{X: 1, Y: 5})
+	if !ok || ship.Name != "Battleship" {
+		t.Errorf("ShipAt(B5) = %v, %v, want Battleship", ship, ok)
+	}
+	if g, _ = store.LoadGameFor(game.Red); len(g.Boards[game.Red].Ships) != 0 {
+		t.Errorf("red board has %d ships, want none", len(g.Boards[game.Red].Ships))
+	}
+}
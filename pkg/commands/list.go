@@ -0,0 +1,85 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"battleship/pkg/database"
+	"battleship/pkg/game"
+)
+
+// ListCommand handles listing the games on the server
+type ListCommand struct {
+	server database.Server
+	out    io.Writer
+}
+
+// NewListCommand creates a new ListCommand
+func NewListCommand(server database.Server) *ListCommand {
+	return &ListCommand{server: server, out: os.Stdout}
+}
+
+// Execute implements the Command interface for ListCommand. The game ID is not used.
+func (c *ListCommand) Execute(gameID string) error {
+	ids, err := c.server.Games()
+	if err != nil {
+		return fmt.Errorf("failed to list games: %v", err)
+	}
+	if len(ids) == 0 {
+		fmt.Fprintln(c.out, "No games have been started.")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(c.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "GAME\tCREATED\tJOINED\tMOVES\tWINNER")
+	for _, id := range ids {
+		created, joined, moves, winner, err := c.summarize(id)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", id, created, joined, moves, winner)
+	}
+	return w.Flush()
+}
+
+// summarize returns the columns listed for a game. A game whose tables have not been
+// created yet, because starting it failed, is listed as not started.
+func (c *ListCommand) summarize(id string) (created, joined, moves, winner string, err error) {
+	db, err := c.server.Open(id)
+	if err != nil {
+		return "", "", "", "", fmt.Errorf("failed to open game %s: %v", id, err)
+	}
+	defer db.Close()
+
+	createdAt, err := db.Created()
+	if errors.Is(err, database.ErrNotStarted) {
+		return "not started", "-", "-", "-", nil
+	}
+	if err != nil {
+		return "", "", "", "", fmt.Errorf("failed to read when game %s was started: %v", id, err)
+	}
+	g, err := db.LoadGame()
+	if err != nil {
+		return "", "", "", "", fmt.Errorf("failed to load game %s: %v", id, err)
+	}
+
+	var teams []string
+	for _, team := range game.Teams {
+		if g.Joined[team] {
+			teams = append(teams, team)
+		}
+	}
+	joined, winner = "-", "-"
+	if len(teams) > 0 {
+		joined = strings.Join(teams, ", ")
+	}
+	if g.Over() {
+		winner = g.Winner
+	}
+
+	return createdAt.Format("2006-01-02 15:04"), joined, fmt.Sprint(g.Move), winner, nil
+}
@@ -0,0 +1,121 @@
+package commands
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"battleship/pkg/database"
+	"battleship/pkg/game"
+)
+
+func TestListCommand(t *testing.T) {
+	server := database.NewMemoryServer()
+
+	var out bytes.Buffer
+	list := NewListCommand(server)
+	list.out = &out
+	if err := list.Execute(""); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if !strings.Contains(out.String(), "No games") {
+		t.Errorf("Execute() with no games printed %q", out.String())
+	}
+
+	// A game in progress with red to play, one that was never initialized and one won by blue
+	started, _ := server.Create("alpha", false)
+	if err := started.Initialize(game.DefaultSettings()); err != nil {
+		t.Fatalf("Initialize() error = %v", err)
+	}
+	if err := started.InsertCoin(game.Red); err != nil {
+		t.Fatalf("InsertCoin() error = %v", err)
+	}
+
+	if _, err := server.Create("beta", false); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	won, _ := server.Create("gamma", false)
+	if err := won.Initialize(oneShipSettings(5)); err != nil {
+		t.Fatalf("Initialize() error = %v", err)
+	}
+	for _, team := range game.Teams {
+		if err := won.InsertCoin(team); err != nil {
+			t.Fatalf("InsertCoin() error = %v", err)
+		}
+		if err := won.InsertShip(team+"_ships", "Destroyer", 0, 0, 2, game.Horizontal); err != nil {
+			t.Fatalf("InsertShip() error = %v", err)
+		}
+	}
+	first, _ := won.TossCoin()
+	moves := []struct {
+		team string
+		x, y int
+	}{{first, 0, 0}, {game.Opponent(first), 4, 4}, {first, 1, 0}}
+	for _, move := range moves {
+		if _, err := won.FireShot(move.team, move.x, move.y); err != nil {
+			t.Fatalf("FireShot() error = %v", err)
+		}
+	}
+
+	out.Reset()
+	if err := list.Execute(""); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("Execute() printed %d lines, want a header and 3 games:\n%s", len(lines), out.String())
+	}
+
+	want := [][]string{
+		{"alpha", "red", "0", "-"},
+		{"beta", "not started", "-", "-", "-"},
+		{"gamma", "red, blue", "3", first},
+	}
+	for i, fields := range want {
+		for _, field := range fields {
+			if !strings.Contains(lines[i+1], field) {
+				t.Errorf("line %q does not contain %q", lines[i+1], field)
+			}
+		}
+	}
+}
+
+// unreachableServer is a Server whose games cannot be read, as if the connection had failed
+type unreachableServer struct {
+	database.Server
+}
+
+func (s unreachableServer) Open(gameID string) (database.Store, error) {
+	store, err := s.Server.Open(gameID)
+	if err != nil {
+		return nil, err
+	}
+	return unreachableStore{store}, nil
+}
+
+// unreachableStore is a Store that fails to read when its game was started
+type unreachableStore struct {
+	database.Store
+}
+
+func (unreachableStore) Created() (time.Time, error) {
+	return time.Time{}, errors.New("connection refused")
+}
+
+func TestListCommandError(t *testing.T) {
+	server := database.NewMemoryServer()
+	if _, err := server.Create("alpha", false); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	// A game that cannot be read is an error, not a game that was never started
+	var out bytes.Buffer
+	list := NewListCommand(unreachableServer{server})
+	list.out = &out
+	if err := list.Execute(""); err == nil {
+		t.Errorf("Execute() with an unreachable game succeeded, want an error:\n%s", out.String())
+	}
+}
@@ -0,0 +1,144 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"battleship/pkg/database"
+	"battleship/pkg/game"
+	"battleship/pkg/terminal"
+)
+
+// PlaceCommand handles joining an existing game with a hand-placed fleet
+type PlaceCommand struct {
+	db   database.Store
+	team string // "red" or "blue"
+}
+
+// NewPlaceCommand creates a new PlaceCommand
+func NewPlaceCommand(db database.Store, team string) *PlaceCommand {
+	return &PlaceCommand{db: db, team: team}
+}
+
+// Execute implements the Command interface for PlaceCommand
+func (c *PlaceCommand) Execute(gameID string) error {
+	if gameID == "" {
+		return fmt.Errorf("place command requires a game ID")
+	}
+	if c.team != "red" && c.team != "blue" {
+		return fmt.Errorf("place command requires a team: red or blue")
+	}
+	fmt.Printf("Joining game with ID: %s as %s team\n", gameID, teamName(c.team))
+
+	return joinGame(c.db, gameID, c.team, c.placeShips)
+}
+
+// placeShips prompts for the bow coordinate and orientation of every ship in the fleet,
+// previewing the board as it fills up, until the player confirms the layout
+func (c *PlaceCommand) placeShips() error {
+	settings, err := c.db.GetSettings()
+	if err != nil {
+		return err
+	}
+
+	board := fmt.Sprintf("%s_ships", c.team)
+	term := terminal.New()
+
+	for {
+		var message string
+		for _, ship := range settings.Fleet {
+			for {
+				if err := c.preview(term, settings, message); err != nil {
+					return err
+				}
+
+				fmt.Printf("Placing %s (length %d)\n", ship.Name, ship.Length)
+				x, y := promptCoordinates("Enter bow coordinates (e.g. D3): ", settings.Width, settings.Height)
+				direction := promptDirection()
+
+				err := c.db.InsertShip(board, ship.Name, x, y, ship.Length, direction)
+				if errors.Is(err, game.ErrInvalidPlacement) {
+					message = fmt.Sprintf("Cannot place %s there: %v", ship.Name, err)
+					continue
+				}
+				if err != nil {
+					return err
+				}
+				message = ""
+				break
+			}
+		}
+
+		if err := c.preview(term, settings, ""); err != nil {
+			return err
+		}
+		if promptYesNo("Use this layout? (y/n): ") {
+			return nil
+		}
+
+		// Start again from an empty board
+		if err := c.db.ClearShips(board); err != nil {
+			return err
+		}
+	}
+}
+
+// preview redraws the player's fleet, followed by an optional message
+func (c *PlaceCommand) preview(term *terminal.Terminal, settings game.Settings, message string) error {
+	g, err := c.db.LoadGameFor(c.team)
+	if err != nil {
+		return err
+	}
+
+	terminal.ClearScreen()
+	term.PrintBoards(settings.Width, settings.Height, g.ShipCells(c.team), nil, nil, "")
+	if message != "" {
+		term.PrintError(message)
+	}
+	return nil
+}
+
+// promptDirection reads a ship orientation from standard input until a valid one is entered
+func promptDirection() game.Direction {
+	var input string
+	for {
+		fmt.Print("Enter orientation (h for horizontal, v for vertical): ")
+		if _, err := fmt.Scan(&input); err != nil {
+			continue
+		}
+
+		switch strings.ToLower(input) {
+		case "h", "horizontal":
+			return game.Horizontal
+		case "v", "vertical":
+			return game.Vertical
+		}
+		fmt.Println("Orientation must be h or v.")
+	}
+}
+
+// promptYesNo asks a yes/no question on standard input until it is answered
+func promptYesNo(prompt string) bool {
+	var input string
+	for {
+		fmt.Print(prompt)
+		if _, err := fmt.Scan(&input); err != nil {
+			continue
+		}
+		if answer, ok := parseYesNo(input); ok {
+			return answer
+		}
+	}
+}
+
+// parseYesNo reads an answer to a yes/no question and reports whether it was one
+func parseYesNo(input string) (bool, bool) {
+	switch strings.ToLower(input) {
+	case "y", "yes":
+		return true, true
+	case "n", "no":
+		return false, true
+	}
+	return false, false
+}
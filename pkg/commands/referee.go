@@ -0,0 +1,180 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"battleship/pkg/database"
+	"battleship/pkg/game"
+)
+
+// RefereeCommand handles refereeing a game: every new commit on the game's branch is
+// compared with its parent by the game rules and a verdict is recorded for it
+type RefereeCommand struct {
+	db       database.Store
+	revert   bool          // revert illegal commits as well as flagging them
+	interval time.Duration // delay between checks for new commits
+	out      io.Writer
+
+	judged   map[string]bool // commits that have been judged
+	reverted map[string]bool // commits the referee has reverted
+}
+
+// NewRefereeCommand creates a new RefereeCommand
+func NewRefereeCommand(db database.Store, revert bool, interval time.Duration) *RefereeCommand {
+	return &RefereeCommand{
+		db:       db,
+		revert:   revert,
+		interval: interval,
+		out:      os.Stdout,
+		judged:   make(map[string]bool),
+		reverted: make(map[string]bool),
+	}
+}
+
+// Execute implements the Command interface for RefereeCommand. It judges each commit as
+// it appears and stops once the game is over and its fleets have been revealed.
+func (c *RefereeCommand) Execute(gameID string) error {
+	if gameID == "" {
+		return fmt.Errorf("referee command requires a game ID")
+	}
+
+	// Carry on from the verdicts of an earlier run
+	verdicts, err := c.db.Verdicts()
+	if err != nil {
+		return fmt.Errorf("failed to read verdicts: %v", err)
+	}
+	for _, v := range verdicts {
+		c.judged[v.Hash] = true
+		c.reverted[v.Hash] = v.Reverted
+	}
+
+	fmt.Fprintf(c.out, "Refereeing game %s...\n", gameID)
+	for {
+		done, err := c.judge()
+		if err != nil {
+			return err
+		}
+		if done {
+			fmt.Fprintln(c.out, "The game is over and every commit has been judged.")
+			return nil
+		}
+		time.Sleep(c.interval)
+	}
+}
+
+// judge records a verdict on every commit that has not been judged yet, oldest first, and
+// reports whether the game is over with its fleets revealed
+func (c *RefereeCommand) judge() (bool, error) {
+	history, err := c.db.History()
+	if err != nil {
+		return false, fmt.Errorf("failed to read game history: %v", err)
+	}
+
+	for i := 1; i < len(history); i++ {
+		commit := history[i]
+		if c.judged[commit.Hash] {
+			continue
+		}
+
+		after, err := c.db.LoadGameAt(commit.Hash)
+		if err != nil {
+			return false, err
+		}
+		// A revert is judged against the state from before the commit it reverted, and
+		// an accepted takeback against the state from before the move it undid
+		parent := i - 1
+		if c.reverted[history[parent].Hash] && parent > 0 {
+			parent--
+		}
+		before, err := c.db.LoadGameAt(history[parent].Hash)
+		if err != nil {
+			return false, err
+		}
+		for commit.TakesBack() && before.Move > after.Move && parent > 0 {
+			parent--
+			if before, err = c.db.LoadGameAt(history[parent].Hash); err != nil {
+				return false, err
+			}
+		}
+		fleets, problems, err := c.fleets(history[parent].Hash, commit.Hash)
+		if err != nil {
+			return false, err
+		}
+		problems = append(problems, game.CheckCommit(before, after, fleets)...)
+		c.judged[commit.Hash] = true
+
+		// The referee's own commits only need a verdict if someone has tampered with them
+		if len(problems) == 0 && commit.ByReferee() {
+			continue
+		}
+
+		v := database.Verdict{Hash: commit.Hash, Message: commit.Message, Problems: problems}
+		if !v.Legal() && c.revert && i == len(history)-1 {
+			if err := c.db.Revert(commit.Hash); err != nil {
+				return false, fmt.Errorf("failed to revert commit %s: %v", commit.Hash, err)
+			}
+			v.Reverted = true
+			c.reverted[commit.Hash] = true
+		}
+		if err := c.db.RecordVerdict(v); err != nil {
+			return false, fmt.Errorf("failed to record verdict: %v", err)
+		}
+		c.print(v)
+
+		// Reverting changed the history; read it again on the next check
+		if v.Reverted {
+			return false, nil
+		}
+	}
+
+	g, err := c.db.LoadGame()
+	if err != nil {
+		return false, err
+	}
+	return g.Over() && g.Revealed, nil
+}
+
+// fleets returns the fleet of each team that had placed one at a commit, as the team saw
+// it then, and a problem for each fleet that stopped matching the layout its team had
+// committed to between the parent commit and that commit
+func (c *RefereeCommand) fleets(parent, commit string) (map[string][]game.Ship, []string, error) {
+	fleets := make(map[string][]game.Ship)
+	var problems []string
+	for _, team := range game.Teams {
+		before, err := c.db.LoadGameForAt(team, parent)
+		if err != nil {
+			return nil, nil, err
+		}
+		after, err := c.db.LoadGameForAt(team, commit)
+		if err != nil {
+			return nil, nil, err
+		}
+		ships := after.Boards[team].Ships
+		if len(ships) == 0 {
+			continue
+		}
+		fleets[team] = ships
+
+		commitment, ok := before.Commitments[team]
+		if ok && commitment.Salt != "" && commitment.Matches(before.Boards[team].Ships) && !commitment.Matches(ships) {
+			problems = append(problems, fmt.Sprintf("the %s fleet was moved after its layout was committed", team))
+		}
+	}
+	return fleets, problems, nil
+}
+
+// print reports a verdict
+func (c *RefereeCommand) print(v database.Verdict) {
+	switch {
+	case v.Legal():
+		fmt.Fprintf(c.out, "ok       %s %s\n", v.Hash, v.Message)
+	case v.Reverted:
+		fmt.Fprintf(c.out, "REVERTED %s %s: %s\n", v.Hash, v.Message, strings.Join(v.Problems, "; "))
+	default:
+		fmt.Fprintf(c.out, "ILLEGAL  %s %s: %s\n", v.Hash, v.Message, strings.Join(v.Problems, "; "))
+	}
+}
@@ -0,0 +1,189 @@
+package commands
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"battleship/pkg/database"
+	"battleship/pkg/game"
+)
+
+func TestRefereeCommand(t *testing.T) {
+	store := database.NewMemory()
+	first := startTestGame(t, store)
+	second := game.Opponent(first)
+	fire(t, store, first, "C3")
+	fire(t, store, second, "A0")
+	fire(t, store, first, "A0")
+	fire(t, store, second, "D4")
+	fire(t, store, first, "B0")
+
+	// A finished game is judged commit by commit and the referee then stops
+	var out bytes.Buffer
+	referee := NewRefereeCommand(store, false, 0)
+	referee.out = &out
+	if err := referee.Execute("testId"); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+
+	verdicts, err := store.Verdicts()
+	if err != nil {
+		t.Fatalf("Verdicts() error = %v", err)
+	}
+	// The join, five moves and the reveal
+	if len(verdicts) != 7 {
+		t.Fatalf("Verdicts() = %d verdicts, want 7:\n%s", len(verdicts), out.String())
+	}
+	for _, v := range verdicts {
+		if !v.Legal() {
+			t.Errorf("verdict on %q = %q, want legal", v.Message, v.Problems)
+		}
+	}
+
+	// Running again finds nothing new to judge
+	if err := NewRefereeCommand(store, false, 0).Execute("testId"); err != nil {
+		t.Fatalf("Execute() again error = %v", err)
+	}
+	if again, _ := store.Verdicts(); len(again) != len(verdicts) {
+		t.Errorf("second run recorded %d verdicts, want %d", len(again), len(verdicts))
+	}
+}
+
+func TestRefereeRevert(t *testing.T) {
+	store := database.NewMemory()
+	first := startTestGame(t, store)
+	second := game.Opponent(first)
+
+	var out bytes.Buffer
+	referee := NewRefereeCommand(store, true, 0)
+	referee.out = &out
+	if done, err := referee.judge(); done || err != nil {
+		t.Fatalf("judge() = %v, %v, want the game in progress", done, err)
+	}
+
+	// The second team moves its destroyer after committing to its layout. Only its private
+	// fleet changes, so the move shows up in the next shot fired at it.
+	if err := store.ClearShips(second + "_ships"); err != nil {
+		t.Fatalf("ClearShips() error = %v", err)
+	}
+	if err := store.InsertShip(second+"_ships", "Destroyer", 3, 4, 2, game.Horizontal); err != nil {
+		t.Fatalf("InsertShip() error = %v", err)
+	}
+	if err := store.Commit("Move the destroyer"); err != nil {
+		t.Fatalf("Commit() error = %v", err)
+	}
+	fire(t, store, first, "D4")
+	if _, err := referee.judge(); err != nil {
+		t.Fatalf("judge() error = %v", err)
+	}
+	if !strings.Contains(out.String(), "REVERTED") {
+		t.Errorf("judge() did not revert the shot at the moved fleet:\n%s", out.String())
+	}
+
+	// The shot is undone and the fleet is put back, so the shot can be fired again
+	g, err := store.LoadGameFor(second)
+	if err != nil {
+		t.Fatalf("LoadGameFor() error = %v", err)
+	}
+	if !g.Commitments[second].Matches(g.Boards[second].Ships) {
+		t.Errorf("%s fleet after the revert = %+v, want the committed layout", second, g.Boards[second].Ships)
+	}
+	if g.Turn != first || len(g.Shots[first]) != 0 {
+		t.Errorf("after the revert turn = %s with %d shots fired, want %s to fire again", g.Turn, len(g.Shots[first]), first)
+	}
+
+	// The revert itself is legal and needs no verdict, and the shot fired again is legal
+	if _, err := referee.judge(); err != nil {
+		t.Fatalf("judge() error = %v", err)
+	}
+	fire(t, store, first, "D4")
+	if _, err := referee.judge(); err != nil {
+		t.Fatalf("judge() error = %v", err)
+	}
+	verdicts, _ := store.Verdicts()
+	if len(verdicts) != 3 || !verdicts[1].Reverted || !verdicts[2].Legal() {
+		t.Errorf("Verdicts() = %+v, want the join, the reverted shot and the legal one", verdicts)
+	}
+}
+
+func TestRefereeLate(t *testing.T) {
+	store := database.NewMemory()
+	first := startTestGame(t, store)
+	second := game.Opponent(first)
+	fire(t, store, first, "A0")
+	fire(t, store, second, "E4")
+
+	// The second team moves its destroyer before the referee has started
+	if err := store.ClearShips(second + "_ships"); err != nil {
+		t.Fatalf("ClearShips() error = %v", err)
+	}
+	if err := store.InsertShip(second+"_ships", "Destroyer", 3, 4, 2, game.Horizontal); err != nil {
+		t.Fatalf("InsertShip() error = %v", err)
+	}
+	if err := store.Commit("Move the destroyer"); err != nil {
+		t.Fatalf("Commit() error = %v", err)
+	}
+	fire(t, store, first, "C2")
+
+	// Each commit is judged against the fleets as they were then, so only the shot fired
+	// after the move is illegal
+	var out bytes.Buffer
+	referee := NewRefereeCommand(store, false, 0)
+	referee.out = &out
+	if _, err := referee.judge(); err != nil {
+		t.Fatalf("judge() error = %v", err)
+	}
+	verdicts, err := store.Verdicts()
+	if err != nil {
+		t.Fatalf("Verdicts() error = %v", err)
+	}
+	for _, v := range verdicts {
+		if legal := !strings.Contains(v.Message, "(C2)"); v.Legal() != legal {
+			t.Errorf("verdict on %q = %q, want legal = %v", v.Message, v.Problems, legal)
+		}
+	}
+	if !strings.Contains(out.String(), "the "+second+" fleet was moved") {
+		t.Errorf("judge() did not report the moved fleet:\n%s", out.String())
+	}
+}
+
+func TestRefereeTakeback(t *testing.T) {
+	store := database.NewMemory()
+	first := startTestGame(t, store)
+	second := game.Opponent(first)
+	fire(t, store, first, "A0")
+
+	// The referee judges the move before it is taken back
+	var out bytes.Buffer
+	referee := NewRefereeCommand(store, true, 0)
+	referee.out = &out
+	if _, err := referee.judge(); err != nil {
+		t.Fatalf("judge() error = %v", err)
+	}
+	if err := store.RequestTakeback(first); err != nil {
+		t.Fatalf("RequestTakeback() error = %v", err)
+	}
+	if err := store.AnswerTakeback(second, true); err != nil {
+		t.Fatalf("AnswerTakeback() error = %v", err)
+	}
+	fire(t, store, first, "B0")
+
+	// The takeback is judged against the state from before the move it undid
+	if _, err := referee.judge(); err != nil {
+		t.Fatalf("judge() error = %v", err)
+	}
+	verdicts, err := store.Verdicts()
+	if err != nil {
+		t.Fatalf("Verdicts() error = %v", err)
+	}
+	for _, v := range verdicts {
+		if !v.Legal() || v.Reverted {
+			t.Errorf("verdict on %q = %q, reverted = %v, want legal", v.Message, v.Problems, v.Reverted)
+		}
+	}
+	// The join, the move, the request, the takeback and the move fired again
+	if len(verdicts) != 5 {
+		t.Errorf("Verdicts() = %d verdicts, want 5:\n%s", len(verdicts), out.String())
+	}
+}
@@ -0,0 +1,121 @@
+package commands
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"battleship/pkg/database"
+	"battleship/pkg/game"
+	"battleship/pkg/terminal"
+)
+
+// ReplayCommand handles replaying a game commit by commit from its history
+type ReplayCommand struct {
+	db    database.Store
+	speed time.Duration // delay between commits when playing automatically; zero to step by hand
+	in    io.Reader
+	out   io.Writer
+}
+
+// NewReplayCommand creates a new ReplayCommand
+func NewReplayCommand(db database.Store, speed time.Duration) *ReplayCommand {
+	return &ReplayCommand{db: db, speed: speed, in: os.Stdin, out: os.Stdout}
+}
+
+// Execute implements the Command interface for ReplayCommand
+func (c *ReplayCommand) Execute(gameID string) error {
+	if gameID == "" {
+		return fmt.Errorf("replay command requires a game ID")
+	}
+
+	history, err := c.db.History()
+	if err != nil {
+		return fmt.Errorf("failed to read game history: %v", err)
+	}
+
+	// The shared branch only holds the fleets once the game is over and they are revealed
+	final, err := c.db.LoadGame()
+	if err != nil {
+		return fmt.Errorf("failed to load game: %v", err)
+	}
+
+	term := terminal.NewWriter(c.out)
+	input := bufio.NewScanner(c.in)
+	current := 0
+	for {
+		commit := history[current]
+		g, err := c.db.LoadGameAt(commit.Hash)
+		if err != nil {
+			return fmt.Errorf("failed to load game at %s: %v", commit.Hash, err)
+		}
+		revealFleets(g, final)
+
+		term.Clear()
+		fmt.Fprintf(c.out, "Game %s, commit %d of %d, %s\n", gameID, current+1, len(history), commit.Date.Format(time.RFC1123))
+		fmt.Fprintln(c.out, commit.Message)
+		printGame(term, g, "")
+		last := current == len(history)-1
+		if last && g.Over() {
+			term.PrintGameOver(g.Winner, g.Resigned, "")
+		}
+
+		// Auto-play runs to the end of the game
+		if c.speed > 0 {
+			if last {
+				return nil
+			}
+			time.Sleep(c.speed)
+			current++
+			continue
+		}
+
+		fmt.Fprint(c.out, "[n]ext, [p]revious, [f]irst, [l]ast or [q]uit: ")
+		if !input.Scan() {
+			return input.Err()
+		}
+		next, quit := replayStep(current, len(history), input.Text())
+		if quit {
+			return nil
+		}
+		current = next
+	}
+}
+
+// revealFleets places the fleets revealed at the end of a game on the state of the game at
+// an earlier commit, for each team that had joined by then
+func revealFleets(g, final *game.Game) {
+	if !final.Revealed {
+		return
+	}
+	for _, team := range game.Teams {
+		if g.Joined[team] && len(g.Boards[team].Ships) == 0 {
+			g.Boards[team].Ships = append([]game.Ship(nil), final.Boards[team].Ships...)
+		}
+	}
+}
+
+// replayStep returns the commit to show after the given key is entered at commit
+// current of total, and whether to stop replaying. Enter on its own steps forward.
+func replayStep(current, total int, key string) (int, bool) {
+	switch strings.ToLower(strings.TrimSpace(key)) {
+	case "", "n", "next":
+		if current < total-1 {
+			current++
+		}
+	case "p", "prev", "previous":
+		if current > 0 {
+			current--
+		}
+	case "f", "first":
+		current = 0
+	case "l", "last":
+		current = total - 1
+	case "q", "quit":
+		return current, true
+	}
+	return current, false
+}
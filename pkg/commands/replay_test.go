@@ -0,0 +1,139 @@
+package commands
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"battleship/pkg/database"
+	"battleship/pkg/game"
+)
+
+func TestReplayStep(t *testing.T) {
+	tests := []struct {
+		current int
+		key     string
+		want    int
+		quit    bool
+	}{
+		{current: 0, key: "", want: 1},
+		{current: 0, key: "n", want: 1},
+		{current: 4, key: "N", want: 4},
+		{current: 2, key: "p", want: 1},
+		{current: 0, key: "previous", want: 0},
+		{current: 3, key: "f", want: 0},
+		{current: 1, key: "l", want: 4},
+		{current: 2, key: "x", want: 2},
+		{current: 2, key: "q", want: 2, quit: true},
+	}
+
+	for _, tt := range tests {
+		got, quit := replayStep(tt.current, 5, tt.key)
+		if got != tt.want || quit != tt.quit {
+			t.Errorf("replayStep(%d, 5, %q) = %d, %v, want %d, %v", tt.current, tt.key, got, quit, tt.want, tt.quit)
+		}
+	}
+}
+
+func TestReplayCommand(t *testing.T) {
+	store := database.NewMemory()
+	if err := store.Initialize(oneShipSettings(5)); err != nil {
+		t.Fatalf("Initialize() error = %v", err)
+	}
+	for _, team := range game.Teams {
+		if err := store.InsertCoin(team); err != nil {
+			t.Fatalf("InsertCoin() error = %v", err)
+		}
+		if err := store.PlaceRandomShips(team); err != nil {
+			t.Fatalf("PlaceRandomShips() error = %v", err)
+		}
+	}
+	first, _ := store.TossCoin()
+	if err := store.Commit("Both teams have joined"); err != nil {
+		t.Fatalf("Commit() error = %v", err)
+	}
+	if _, err := store.FireShot(first, 0, 0); err != nil {
+		t.Fatalf("FireShot() error = %v", err)
+	}
+
+	// Step through, back and to the end, then stop when the input runs out
+	var out bytes.Buffer
+	replay := NewReplayCommand(store, 0)
+	replay.in = strings.NewReader("n\np\nl\n")
+	replay.out = &out
+	if err := replay.Execute("testId"); err != nil {
+		t.Errorf("Execute() error = %v", err)
+	}
+	history, err := store.History()
+	if err != nil {
+		t.Fatalf("History() error = %v", err)
+	}
+	for _, commit := range history {
+		if !strings.Contains(out.String(), commit.Message) {
+			t.Errorf("Execute() output does not show commit %q", commit.Message)
+		}
+	}
+	// One screen for the first commit and one for each key entered
+	if got, want := strings.Count(out.String(), "[n]ext, [p]revious"), 4; got != want {
+		t.Errorf("Execute() prompted %d times, want %d", got, want)
+	}
+
+	// Auto-play stops by itself at the last commit
+	out.Reset()
+	auto := NewReplayCommand(store, 1)
+	auto.out = &out
+	if err := auto.Execute("testId"); err != nil {
+		t.Errorf("Execute() with a speed error = %v", err)
+	}
+	if got, want := strings.Count(out.String(), "Game testId, commit"), len(history); got != want {
+		t.Errorf("Execute() with a speed showed %d commits, want %d", got, want)
+	}
+
+	if err := NewReplayCommand(database.NewMemory(), 0).Execute("testId"); err == nil {
+		t.Error("Execute() of a game that was never started succeeded, want an error")
+	}
+}
+
+func TestRevealFleets(t *testing.T) {
+	store := database.NewMemory()
+	first := startTestGame(t, store)
+	fire(t, store, first, "A0")
+	fire(t, store, game.Opponent(first), "E4")
+
+	// Before the game is over the fleets stay hidden
+	history, err := store.History()
+	if err != nil {
+		t.Fatalf("History() error = %v", err)
+	}
+	g, err := store.LoadGameAt(history[len(history)-1].Hash)
+	if err != nil {
+		t.Fatalf("LoadGameAt() error = %v", err)
+	}
+	final, err := store.LoadGame()
+	if err != nil {
+		t.Fatalf("LoadGame() error = %v", err)
+	}
+	revealFleets(g, final)
+	if len(g.Boards[game.Red].Ships) != 0 {
+		t.Errorf("fleet shown before the game was over: %v", g.Boards[game.Red].Ships)
+	}
+
+	// Once revealed, every commit after the joins shows both fleets
+	fire(t, store, first, "B0")
+	final, err = store.LoadGame()
+	if err != nil {
+		t.Fatalf("LoadGame() error = %v", err)
+	}
+	for i, commit := range history {
+		g, err := store.LoadGameAt(commit.Hash)
+		if err != nil {
+			t.Fatalf("LoadGameAt() error = %v", err)
+		}
+		revealFleets(g, final)
+		for _, team := range game.Teams {
+			if want := g.Joined[team]; (len(g.Boards[team].Ships) == 1) != want {
+				t.Errorf("commit %d: %s ships = %v, want them shown = %v", i, team, g.Boards[team].Ships, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package commands
+
+import (
+	"fmt"
+
+	"battleship/pkg/database"
+	"battleship/pkg/game"
+)
+
+// ResignCommand handles a team resigning, which ends the game with a win for the other team
+type ResignCommand struct {
+	db   database.Store
+	team string // "red" or "blue"
+}
+
+// NewResignCommand creates a new ResignCommand
+func NewResignCommand(db database.Store, team string) *ResignCommand {
+	return &ResignCommand{db: db, team: team}
+}
+
+// Execute implements the Command interface for ResignCommand. Both teams' watch loops
+// show the result and stop once it has been committed.
+func (c *ResignCommand) Execute(gameID string) error {
+	if gameID == "" {
+		return fmt.Errorf("resign command requires a game ID")
+	}
+	if !game.ValidTeam(c.team) {
+		return fmt.Errorf("resign command requires a team: red or blue")
+	}
+
+	if err := c.db.Resign(c.team); err != nil {
+		return fmt.Errorf("failed to resign: %w", err)
+	}
+
+	fmt.Printf("%s team has resigned; the %s team wins.\n", teamName(c.team), game.Opponent(c.team))
+	return nil
+}
@@ -0,0 +1,47 @@
+package commands
+
+import (
+	"errors"
+	"testing"
+
+	"battleship/pkg/database"
+	"battleship/pkg/game"
+)
+
+func TestResignCommand(t *testing.T) {
+	store := database.NewMemory()
+	first := startTestGame(t, store)
+	fire(t, store, first, "C3")
+
+	if err := NewResignCommand(store, "green").Execute("testId"); err == nil {
+		t.Error("Execute() for an unknown team succeeded, want an error")
+	}
+	if err := NewResignCommand(store, first).Execute("testId"); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+
+	g, err := store.LoadGame()
+	if err != nil {
+		t.Fatalf("LoadGame() error = %v", err)
+	}
+	if g.Winner != game.Opponent(first) || g.Resigned != first {
+		t.Errorf("after resigning winner, resigned = %q, %q, want %q, %q", g.Winner, g.Resigned, game.Opponent(first), first)
+	}
+
+	// The resignation is committed before the fleets are revealed and the last shot did not win the game
+	history, _ := store.History()
+	if resigned := history[len(history)-2].Message; resigned != "Team "+first+" resigned; team "+game.Opponent(first)+" has won the game" {
+		t.Errorf("commit before the reveal = %q, want the resignation", resigned)
+	}
+	if !g.Revealed {
+		t.Error("fleets not revealed after resigning")
+	}
+	moves, _ := store.Moves()
+	if len(moves) != 1 || moves[0].Winner != "" {
+		t.Errorf("Moves() = %+v, want one move that did not win", moves)
+	}
+
+	if err := NewResignCommand(store, game.Opponent(first)).Execute("testId"); !errors.Is(err, game.ErrGameOver) {
+		t.Errorf("Execute() after the game ended error = %v, want %v", err, game.ErrGameOver)
+	}
+}
@@ -0,0 +1,37 @@
+package commands
+
+import (
+	"fmt"
+
+	"battleship/pkg/database"
+	"battleship/pkg/game"
+)
+
+// UndoCommand handles asking the other team to let a team take back its last move
+type UndoCommand struct {
+	db   database.Store
+	team string // "red" or "blue"
+}
+
+// NewUndoCommand creates a new UndoCommand
+func NewUndoCommand(db database.Store, team string) *UndoCommand {
+	return &UndoCommand{db: db, team: team}
+}
+
+// Execute implements the Command interface for UndoCommand. The move is taken back
+// once the other team accepts the request in their watch loop.
+func (c *UndoCommand) Execute(gameID string) error {
+	if gameID == "" {
+		return fmt.Errorf("undo command requires a game ID")
+	}
+	if !game.ValidTeam(c.team) {
+		return fmt.Errorf("undo command requires a team: red or blue")
+	}
+
+	if err := c.db.RequestTakeback(c.team); err != nil {
+		return fmt.Errorf("failed to request takeback: %w", err)
+	}
+
+	fmt.Printf("%s team has asked to take back their last move; waiting for the %s team to accept.\n", teamName(c.team), game.Opponent(c.team))
+	return nil
+}
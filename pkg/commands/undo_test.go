@@ -0,0 +1,72 @@
+package commands
+
+import (
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"battleship/pkg/database"
+	"battleship/pkg/game"
+)
+
+func TestUndoCommand(t *testing.T) {
+	store := database.NewMemory()
+	first := startTestGame(t, store)
+
+	if err := NewUndoCommand(store, first).Execute("testId"); !errors.Is(err, game.ErrNothingToTakeBack) {
+		t.Errorf("Execute() before any move error = %v, want %v", err, game.ErrNothingToTakeBack)
+	}
+	if err := NewUndoCommand(store, "green").Execute("testId"); err == nil {
+		t.Error("Execute() for an unknown team succeeded, want an error")
+	}
+
+	fire(t, store, first, "C3")
+	if err := NewUndoCommand(store, first).Execute("testId"); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	g, err := store.LoadGame()
+	if err != nil {
+		t.Fatalf("LoadGame() error = %v", err)
+	}
+	if g.Takeback != first {
+		t.Errorf("Takeback = %q, want %q", g.Takeback, first)
+	}
+}
+
+func TestWatchTakebackWhileWaiting(t *testing.T) {
+	store := database.NewMemory()
+	first := startTestGame(t, store)
+	second := game.Opponent(first)
+	fire(t, store, first, "C3")
+
+	// The player to move has yet to type anything when the takeback is asked for
+	input, typed := io.Pipe()
+	defer typed.Close()
+	watch := NewWatchCommand(store, second)
+	watch.in = input
+	requested := make(chan error, 1)
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		requested <- store.RequestTakeback(first)
+	}()
+	if _, ok, err := watch.read("", true); ok || err != nil {
+		t.Fatalf("read() while the takeback was asked for = %v, %v, want it to give up", ok, err)
+	}
+	if err := <-requested; err != nil {
+		t.Fatalf("RequestTakeback() error = %v", err)
+	}
+
+	// The request is then answered from the same input
+	go typed.Write([]byte("y\n"))
+	g, err := store.LoadGame()
+	if err != nil {
+		t.Fatalf("LoadGame() error = %v", err)
+	}
+	if err := watch.answerTakeback(g); err != nil {
+		t.Fatalf("answerTakeback() error = %v", err)
+	}
+	if g, _ := store.LoadGame(); g.Move != 0 || g.Turn != first {
+		t.Errorf("after accepting move, turn = %d, %q, want 0 and %q", g.Move, g.Turn, first)
+	}
+}
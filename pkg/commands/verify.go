@@ -0,0 +1,74 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"battleship/pkg/database"
+	"battleship/pkg/game"
+)
+
+// VerifyCommand handles checking a finished game for cheating: each fleet revealed at the
+// end must match the commitment its team published when it joined, and every recorded
+// shot must have the result the revealed fleets give
+type VerifyCommand struct {
+	db  database.Store
+	out io.Writer
+}
+
+// NewVerifyCommand creates a new VerifyCommand
+func NewVerifyCommand(db database.Store) *VerifyCommand {
+	return &VerifyCommand{db: db, out: os.Stdout}
+}
+
+// Execute implements the Command interface for VerifyCommand. It reports each check and
+// returns an error if any of them fails.
+func (c *VerifyCommand) Execute(gameID string) error {
+	if gameID == "" {
+		return fmt.Errorf("verify command requires a game ID")
+	}
+
+	g, err := c.db.LoadGame()
+	if err != nil {
+		return fmt.Errorf("failed to load game: %v", err)
+	}
+	if !g.Over() || !g.Revealed {
+		return fmt.Errorf("game %s cannot be verified until it is over and the fleets have been revealed", gameID)
+	}
+
+	failed := false
+	for _, team := range game.Teams {
+		commitment, ok := g.Commitments[team]
+		switch {
+		case !ok:
+			fmt.Fprintf(c.out, "FAIL %s team never committed to its fleet layout\n", teamName(team))
+			failed = true
+		case !commitment.Matches(g.Boards[team].Ships):
+			fmt.Fprintf(c.out, "FAIL %s fleet does not match the commitment %s\n", teamName(team), commitment.Hash)
+			failed = true
+		default:
+			fmt.Fprintf(c.out, "ok   %s fleet matches the commitment %s\n", teamName(team), commitment.Hash)
+		}
+	}
+
+	moves, err := c.db.Moves()
+	if err != nil {
+		return fmt.Errorf("failed to read game history: %v", err)
+	}
+	shots := make([]game.Shot, len(moves))
+	for i, move := range moves {
+		shots[i] = move.Shot
+	}
+	if err := g.CheckShots(shots); err != nil {
+		fmt.Fprintf(c.out, "FAIL %v\n", err)
+		failed = true
+	} else {
+		fmt.Fprintf(c.out, "ok   all %d recorded shots match the revealed fleets\n", len(shots))
+	}
+
+	if failed {
+		return fmt.Errorf("game %s failed verification", gameID)
+	}
+	return nil
+}
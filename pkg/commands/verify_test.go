@@ -0,0 +1,62 @@
+package commands
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"battleship/pkg/database"
+	"battleship/pkg/game"
+)
+
+func TestVerifyCommand(t *testing.T) {
+	store := database.NewMemory()
+	first := startTestGame(t, store)
+	second := game.Opponent(first)
+
+	verify := NewVerifyCommand(store)
+	var out bytes.Buffer
+	verify.out = &out
+	if err := verify.Execute("testId"); err == nil {
+		t.Error("Execute() of a game in progress succeeded, want an error")
+	}
+
+	fire(t, store, first, "A0")
+	fire(t, store, second, "E4")
+	fire(t, store, first, "B0")
+
+	if err := verify.Execute("testId"); err != nil {
+		t.Fatalf("Execute() of an honest game error = %v\n%s", err, out.String())
+	}
+	if strings.Contains(out.String(), "FAIL") {
+		t.Errorf("Execute() of an honest game printed a failure:\n%s", out.String())
+	}
+}
+
+func TestVerifyCommandCheat(t *testing.T) {
+	store := database.NewMemory()
+	first := startTestGame(t, store)
+	second := game.Opponent(first)
+
+	// The second team moves its destroyer after committing to its layout
+	if err := store.ClearShips(second + "_ships"); err != nil {
+		t.Fatalf("ClearShips() error = %v", err)
+	}
+	if err := store.InsertShip(second+"_ships", "Destroyer", 3, 4, 2, game.Horizontal); err != nil {
+		t.Fatalf("InsertShip() error = %v", err)
+	}
+	fire(t, store, first, "A0")
+	fire(t, store, second, "A0")
+	fire(t, store, first, "B0")
+	fire(t, store, second, "B0")
+
+	var out bytes.Buffer
+	verify := NewVerifyCommand(store)
+	verify.out = &out
+	if err := verify.Execute("testId"); err == nil {
+		t.Fatalf("Execute() of a game with a moved fleet succeeded, want an error\n%s", out.String())
+	}
+	if !strings.Contains(out.String(), "FAIL "+teamName(second)+" fleet does not match") {
+		t.Errorf("Execute() did not report the moved %s fleet:\n%s", second, out.String())
+	}
+}
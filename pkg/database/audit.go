@@ -0,0 +1,135 @@
+package database
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"battleship/pkg/game"
+)
+
+// Anomaly is something found in the history of a game that playing by the rules never commits
+type Anomaly struct {
+	Branch  string // branch holding the commit
+	Hash    string // commit where the anomaly was found
+	Message string // message of that commit
+	Problem string
+}
+
+// change is a row changed by a commit in one of the tables the audit checks
+type change struct {
+	table    string // ships, board_states or coin
+	key      string // board of a ships or board_states row, or team of a coin row
+	diffType string // added, modified or removed
+}
+
+// auditedCommit is a commit with the number of parents it has and the rows it changed
+type auditedCommit struct {
+	Commit
+	parents int
+	changes []change
+}
+
+// commitMessages match the message of every commit made by the commands and the stores
+var commitMessages = []*regexp.Regexp{
+	regexp.MustCompile(`^` + regexp.QuoteMeta(initializeMessage) + `$`),
+	regexp.MustCompile(`^(Red|Blue) team has joined the game and placed their ships(; (red|blue) team won the coin toss and moves first)?$`),
+	regexp.MustCompile(`^(Red|Blue) team has resumed the game( and committed to its fleet layout)?(; (red|blue) team won the coin toss and moves first)?$`),
+	regexp.MustCompile(`^Team (red|blue) shot at \([A-Z][0-9]+\) and it was a (miss|hit|hit and sunk .+?)(; team (red|blue) has sunk the entire enemy fleet and won the game)?$`),
+	regexp.MustCompile(`^Team (red|blue) resigned; team (red|blue) has won the game$`),
+	regexp.MustCompile(`^Team (red|blue) (asked to take back|agreed to take back|declined to take back) move [0-9]+( by team (red|blue))?$`),
+	regexp.MustCompile(`^` + regexp.QuoteMeta(revealMessage) + `$`),
+	regexp.MustCompile(`^` + regexp.QuoteMeta(refereePrefix)),
+}
+
+// expectedMessage reports whether a commit message is one the game itself writes
+func expectedMessage(message string) bool {
+	for _, pattern := range commitMessages {
+		if pattern.MatchString(message) {
+			return true
+		}
+	}
+	return false
+}
+
+// audit checks the commits of a branch, oldest first, starting with the commit that
+// started the game. On the shared branch fleets may only appear when they are revealed;
+// on a private branch a fleet may only be placed once, by the join commit. A team's coin
+// row may be added once and then only changed to complete its commitment on resuming or
+// to reveal its salt.
+func audit(branch string, commits []auditedCommit, shared bool) []Anomaly {
+	var anomalies []Anomaly
+	report := func(c auditedCommit, format string, args ...interface{}) {
+		anomalies = append(anomalies, Anomaly{Branch: branch, Hash: c.Hash, Message: c.Message, Problem: fmt.Sprintf(format, args...)})
+	}
+
+	placed := make(map[string]bool) // boards whose fleet has been placed
+	joined := make(map[string]bool) // teams whose coin row has been added
+	for _, c := range commits {
+		if !expectedMessage(c.Message) {
+			report(c, "unexpected commit message")
+		}
+		if c.parents > 1 {
+			report(c, "merge commit with %d parents; the history is not linear", c.parents)
+		}
+
+		revealing := c.Message == revealMessage
+		resuming := strings.Contains(c.Message, "team has resumed the game")
+		boards := make(map[string]bool)
+		for _, ch := range c.changes {
+			switch ch.table {
+			case "ships", "board_states":
+				switch {
+				case shared && !revealing:
+					report(c, "%s row on %s %s before the fleets were revealed", ch.table, ch.key, ch.diffType)
+				case !shared && placed[ch.key]:
+					report(c, "%s row on %s %s after the fleet was placed", ch.table, ch.key, ch.diffType)
+				}
+				boards[ch.key] = true
+			case "coin":
+				switch {
+				case ch.diffType == "removed":
+					report(c, "coin row of team %s removed", ch.key)
+				case ch.diffType == "added" && joined[ch.key]:
+					report(c, "coin row of team %s added again", ch.key)
+				case ch.diffType == "modified" && !revealing && !resuming:
+					report(c, "coin row of team %s modified", ch.key)
+				}
+				joined[ch.key] = true
+			}
+		}
+		for board := range boards {
+			placed[board] = true
+		}
+	}
+	return anomalies
+}
+
+// snapshotChanges returns the changes between two snapshots of a game, in terms of the
+// rows Database would have changed
+func snapshotChanges(before, after *game.Game) []change {
+	var changes []change
+	for _, team := range game.Teams {
+		if !reflect.DeepEqual(before.Boards[team].Ships, after.Boards[team].Ships) {
+			diffType := "modified"
+			switch {
+			case len(before.Boards[team].Ships) == 0:
+				diffType = "added"
+			case len(after.Boards[team].Ships) == 0:
+				diffType = "removed"
+			}
+			changes = append(changes, change{table: "ships", key: team + "_ships", diffType: diffType})
+		}
+
+		switch {
+		case !before.Joined[team] && after.Joined[team]:
+			changes = append(changes, change{table: "coin", key: team, diffType: "added"})
+		case before.Joined[team] && !after.Joined[team]:
+			changes = append(changes, change{table: "coin", key: team, diffType: "removed"})
+		case before.Commitments[team] != after.Commitments[team]:
+			changes = append(changes, change{table: "coin", key: team, diffType: "modified"})
+		}
+	}
+	return changes
+}
@@ -0,0 +1,65 @@
+package database
+
+import "testing"
+
+func TestExpectedMessage(t *testing.T) {
+	tests := []struct {
+		message string
+		want    bool
+	}{
+		{initializeMessage, true},
+		{"Red team has joined the game and placed their ships", true},
+		{"Blue team has joined the game and placed their ships; red team won the coin toss and moves first", true},
+		{"Blue team has resumed the game and committed to its fleet layout", true},
+		{"Team red shot at (A0) and it was a miss", true},
+		{"Team blue shot at (C4) and it was a hit and sunk Destroyer; team blue has sunk the entire enemy fleet and won the game", true},
+		{"Team red resigned; team blue has won the game", true},
+		{"Team blue asked to take back move 3", true},
+		{revealMessage, true},
+		{refereePrefix + "commit abc is legal", true},
+		{"Move the red destroyer", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		if got := expectedMessage(tt.message); got != tt.want {
+			t.Errorf("expectedMessage(%q) = %v, want %v", tt.message, got, tt.want)
+		}
+	}
+}
+
+func TestAudit(t *testing.T) {
+	const join = "Red team has joined the game and placed their ships"
+	const shot = "Team red shot at (A0) and it was a miss"
+	commit := func(message string, changes ...change) auditedCommit {
+		return auditedCommit{Commit: Commit{Hash: message, Message: message}, parents: 1, changes: changes}
+	}
+	placed := []change{{"coin", "red", "added"}, {"ships", "red_ships", "added"}, {"board_states", "red_ships", "added"}}
+
+	tests := []struct {
+		name     string
+		commits  []auditedCommit
+		shared   bool
+		problems int
+	}{
+		{"honest private branch", []auditedCommit{commit(initializeMessage), commit(join, placed...), commit(shot)}, false, 0},
+		{"honest shared branch", []auditedCommit{
+			commit(initializeMessage),
+			commit(join, change{"coin", "red", "added"}),
+			commit(revealMessage, change{"coin", "red", "modified"}, change{"ships", "red_ships", "added"}),
+		}, true, 0},
+		{"ship moved", []auditedCommit{commit(initializeMessage), commit(join, placed...), commit(shot, change{"ships", "red_ships", "modified"})}, false, 1},
+		{"fleet on shared branch", []auditedCommit{commit(initializeMessage), commit(join, placed...)}, true, 2},
+		{"coin removed", []auditedCommit{commit(join, placed...), commit(shot, change{"coin", "red", "removed"})}, false, 1},
+		{"coin added again", []auditedCommit{commit(join, placed...), commit(shot, change{"coin", "red", "added"})}, false, 1},
+		{"coin modified", []auditedCommit{commit(join, placed...), commit(shot, change{"coin", "red", "modified"})}, false, 1},
+		{"unexpected message", []auditedCommit{commit(join, placed...), commit("Fix the board")}, false, 1},
+		{"merge", []auditedCommit{commit(join, placed...), {Commit: Commit{Message: shot}, parents: 2}}, false, 1},
+	}
+
+	for _, tt := range tests {
+		if anomalies := audit("test", tt.commits, tt.shared); len(anomalies) != tt.problems {
+			t.Errorf("%s: audit() = %+v, want %d anomalies", tt.name, anomalies, tt.problems)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package database
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// ErrUnreachable is wrapped by the error returned when the Dolt sql-server cannot be reached
+var ErrUnreachable = errors.New("cannot reach the Dolt sql-server")
+
+// errUnknownDatabase is the MySQL error number for a database or branch that does not exist
+const errUnknownDatabase = 1049
+
+// Config holds the settings used to connect to a Dolt sql-server
+type Config struct {
+	User     string
+	Password string
+	Addr     string // host:port of the sql-server
+	Database string // database holding the games; each game is a branch of it
+}
+
+// DefaultConfig returns the settings of a local sql-server started with `dolt sql-server --port 9889`
+func DefaultConfig() Config {
+	return Config{
+		User:     "root",
+		Password: "",
+		Addr:     "localhost:9889",
+		Database: "battleship",
+	}
+}
+
+// DefaultConfigFile returns the path of the config file read when none is given,
+// e.g. ~/.config/battleship/config on Linux
+func DefaultConfigFile() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "battleship", "config")
+}
+
+// ParseDSN overrides the settings given in a MySQL data source name such as
+// "root:secret@tcp(dolt.example.com:3306)/battleship". Parts missing from the DSN,
+// such as the database, are left unchanged.
+func (c *Config) ParseDSN(dsn string) error {
+	parsed, err := mysql.ParseDSN(dsn)
+	if err != nil {
+		return fmt.Errorf("invalid DSN: %v", err)
+	}
+
+	if parsed.User != "" {
+		c.User = parsed.User
+		c.Password = parsed.Passwd
+	}
+	if parsed.Addr != "" && dsnHasAddr(dsn) {
+		c.Addr = parsed.Addr
+	}
+	if parsed.DBName != "" {
+		c.Database = parsed.DBName
+	}
+	return nil
+}
+
+// dsnHasAddr reports whether a DSN names an address, as in tcp(host:port). Without one
+// the driver fills in its own default, which should not replace ours.
+func dsnHasAddr(dsn string) bool {
+	rest := dsn[strings.LastIndex(dsn, "@")+1:]
+	open := strings.Index(rest, "(")
+	return open >= 0 && !strings.HasPrefix(rest[open:], "()")
+}
+
+// LoadFile overrides the settings given in a config file. Each non-empty line is a
+// "key = value" pair, where the key is one of dsn, user, password, addr or database;
+// lines starting with # are ignored.
+func (c *Config) LoadFile(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open config file: %v", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return fmt.Errorf("%s:%d: expected <key> = <value>", path, lineNumber)
+		}
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+
+		switch strings.ToLower(key) {
+		case "dsn":
+			if err := c.ParseDSN(value); err != nil {
+				return fmt.Errorf("%s:%d: %v", path, lineNumber, err)
+			}
+		case "user":
+			c.User = value
+		case "password":
+			c.Password = value
+		case "addr":
+			c.Addr = value
+		case "database":
+			c.Database = value
+		default:
+			return fmt.Errorf("%s:%d: unknown setting %q", path, lineNumber, key)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read config file: %v", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,89 @@
+package database
+
+import (
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestConfigParseDSN(t *testing.T) {
+	tests := []struct {
+		dsn     string
+		want    Config
+		wantErr bool
+	}{
+		{
+			dsn:  "alice:secret@tcp(dolt.example.com:3306)/games",
+			want: Config{User: "alice", Password: "secret", Addr: "dolt.example.com:3306", Database: "games"},
+		},
+		{
+			dsn:  "alice@tcp(10.0.0.5:9889)/",
+			want: Config{User: "alice", Password: "", Addr: "10.0.0.5:9889", Database: "battleship"},
+		},
+		{
+			// Without an address the default one is kept rather than the driver's
+			dsn:  "bob:pw@/games",
+			want: Config{User: "bob", Password: "pw", Addr: "localhost:9889", Database: "games"},
+		},
+		{dsn: "not a dsn", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.dsn, func(t *testing.T) {
+			config := DefaultConfig()
+			err := config.ParseDSN(tt.dsn)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseDSN(%q) error = %v, wantErr %v", tt.dsn, err, tt.wantErr)
+			}
+			if !tt.wantErr && config != tt.want {
+				t.Errorf("ParseDSN(%q) = %+v, want %+v", tt.dsn, config, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfigLoadFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config")
+	contents := `# Team server
+addr = dolt.example.com:3306
+user = alice
+password = p=w
+`
+	if err := os.WriteFile(path, []byte(contents), 0o600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	config := DefaultConfig()
+	if err := config.LoadFile(path); err != nil {
+		t.Fatalf("LoadFile() error = %v", err)
+	}
+	want := Config{User: "alice", Password: "p=w", Addr: "dolt.example.com:3306", Database: "battleship"}
+	if config != want {
+		t.Errorf("LoadFile() = %+v, want %+v", config, want)
+	}
+
+	if err := os.WriteFile(path, []byte("port = 3306\n"), 0o600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	if err := config.LoadFile(path); err == nil {
+		t.Error("LoadFile() with an unknown setting succeeded, want an error")
+	}
+}
+
+func TestNewUnreachable(t *testing.T) {
+	// Find a port with nothing listening on it
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	addr := listener.Addr().String()
+	listener.Close()
+
+	config := DefaultConfig()
+	config.Addr = addr
+	if _, err := New(config, "testId"); !errors.Is(err, ErrUnreachable) {
+		t.Errorf("New() with no server error = %v, want %v", err, ErrUnreachable)
+	}
+}
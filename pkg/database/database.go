@@ -2,29 +2,105 @@ package database
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 	"log"
-	"math/rand"
+	"net"
+	"sort"
+	"strings"
 	"time"
 
+	"battleship/pkg/game"
+
 	"github.com/go-sql-driver/mysql"
 )
 
-// Database handles Dolt database operations
+// initializeMessage is the message of the commit that starts a game
+const initializeMessage = "Create board_states, ships, coin, game_state, settings and fleet tables"
+
+// revealMessage is the message of the commit that makes both fleets public at the end of a game
+const revealMessage = "Reveal both fleets and the salts of their layout commitments"
+
+// refereePrefix starts the message of every commit made by the referee
+const refereePrefix = "Referee: "
+
+// querier is the subset of *sql.DB and *sql.Tx used by helpers that may run inside a transaction
+type querier interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// Database handles Dolt database operations. The shared state of a game, which both
+// teams may read, is kept on the branch game_<id>; each team's fleet is kept on its own
+// private branch so that the shared branch never reveals where the ships are.
 type Database struct {
-	db *sql.DB
+	db     *sql.DB
+	gameID string
+	config Config
+	fleets map[string]*sql.DB // connections to each team's private branch, opened when first used
+}
+
+// New connects to the game with the given ID, which is stored on the branch game_<id>
+// of the database named in config
+func New(config Config, gameId string) (*Database, error) {
+	db, err := connect(config, fmt.Sprintf("%s/%s", config.Database, branchName(gameId)))
+	if err != nil {
+		var mysqlErr *mysql.MySQLError
+		if errors.As(err, &mysqlErr) && mysqlErr.Number == errUnknownDatabase {
+			return nil, fmt.Errorf("%w: %s (start it first)", ErrGameNotFound, gameId)
+		}
+		return nil, err
+	}
+
+	return &Database{
+		db:     db,
+		gameID: gameId,
+		config: config,
+		fleets: make(map[string]*sql.DB),
+	}, nil
+}
+
+// branchName returns the name of the Dolt branch holding a game
+func branchName(gameID string) string {
+	return fmt.Sprintf("game_%s", gameID)
 }
 
-// New creates a new Database instance
-func New(gameId string) (*Database, error) {
+// privateBranchName returns the name of the Dolt branch holding a team's fleet
+func privateBranchName(gameID, team string) string {
+	return fmt.Sprintf("private_%s_%s", gameID, team)
+}
+
+// fleetDB returns the connection to a team's private branch
+func (d *Database) fleetDB(team string) (*sql.DB, error) {
+	if !game.ValidTeam(team) {
+		return nil, fmt.Errorf("invalid team: %s", team)
+	}
+	if db, ok := d.fleets[team]; ok {
+		return db, nil
+	}
+
+	db, err := connect(d.config, fmt.Sprintf("%s/%s", d.config.Database, privateBranchName(d.gameID, team)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to the %s fleet: %w", team, err)
+	}
+	d.fleets[team] = db
+	return db, nil
+}
+
+// connect opens a connection to the sql-server described by config using the given
+// database, or no database if it is empty, and checks that the server can be reached
+func connect(config Config, database string) (*sql.DB, error) {
 	// Configure the database connection
 	cfg := mysql.NewConfig()
-	cfg.User = "root"
-	cfg.Passwd = ""
-	cfg.Addr = "localhost:9889"
-	cfg.DBName = fmt.Sprintf("battleship/game_%s", gameId)
+	cfg.User = config.User
+	cfg.Passwd = config.Password
+	cfg.Net = "tcp"
+	cfg.Addr = config.Addr
+	cfg.DBName = database
 	cfg.ParseTime = true
 	cfg.Loc = time.Local
+	cfg.Timeout = 5 * time.Second
 
 	// Create the connector
 	connector, err := mysql.NewConnector(cfg)
@@ -37,16 +113,125 @@ func New(gameId string) (*Database, error) {
 
 	// Test the connection
 	if err := db.Ping(); err != nil {
-		return nil, fmt.Errorf("failed to ping database: %v", err)
+		db.Close()
+		var netErr net.Error
+		if errors.As(err, &netErr) {
+			return nil, fmt.Errorf("%w at %s (is `dolt sql-server` running?): %v", ErrUnreachable, config.Addr, err)
+		}
+		return nil, fmt.Errorf("failed to ping database: %w", err)
 	}
 
-	return &Database{
-		db: db,
-	}, nil
+	return db, nil
+}
+
+// Create makes a new, empty game with the given ID, creating the database named in
+// config if the server does not have it yet. An existing game is only replaced when
+// force is set; otherwise ErrGameExists is returned. Call Initialize to set up the game.
+func Create(config Config, gameId string, force bool) (*Database, error) {
+	server, err := connect(config, "")
+	if err != nil {
+		return nil, err
+	}
+	defer server.Close()
+
+	if _, err := server.Exec(fmt.Sprintf("CREATE DATABASE IF NOT EXISTS %s", quoteIdentifier(config.Database))); err != nil {
+		return nil, fmt.Errorf("failed to create database %s: %v", config.Database, err)
+	}
+
+	// Each game is a branch of the database, created from its default branch
+	db, err := connect(config, config.Database)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	branch := branchName(gameId)
+	var count int
+	if err := db.QueryRow("SELECT COUNT(*) FROM dolt_branches WHERE name = ?", branch).Scan(&count); err != nil {
+		return nil, fmt.Errorf("failed to query branches: %v", err)
+	}
+	if count > 0 && !force {
+		return nil, fmt.Errorf("%w: %s (use --force to replace it)", ErrGameExists, gameId)
+	}
+
+	if _, err := db.Exec("CALL DOLT_BRANCH('-f', ?)", branch); err != nil {
+		return nil, fmt.Errorf("failed to create branch %s: %v", branch, err)
+	}
+
+	// Tags belong to the whole database, so the result of a replaced game must go too
+	if count > 0 {
+		if err := deleteWinnerTags(db, gameId); err != nil {
+			return nil, err
+		}
+	}
+
+	return New(config, gameId)
+}
+
+// deleteWinnerTags deletes the tags recording the result of a game
+func deleteWinnerTags(q querier, gameID string) error {
+	for _, team := range game.Teams {
+		tag := winnerTag(gameID, team)
+		var count int
+		if err := q.QueryRow("SELECT COUNT(*) FROM dolt_tags WHERE tag_name = ?", tag).Scan(&count); err != nil {
+			return fmt.Errorf("failed to query tags: %v", err)
+		}
+		if count == 0 {
+			continue
+		}
+		if _, err := q.Exec("CALL DOLT_TAG('-d', ?)", tag); err != nil {
+			return fmt.Errorf("failed to delete tag %s: %v", tag, err)
+		}
+	}
+	return nil
+}
+
+// Games returns the IDs of the games in the database named in config, in alphabetical
+// order. A server without the database has no games.
+func Games(config Config) ([]string, error) {
+	db, err := connect(config, config.Database)
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) && mysqlErr.Number == errUnknownDatabase {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	rows, err := db.Query("SELECT name FROM dolt_branches WHERE name LIKE 'game\\_%' ORDER BY name")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query branches: %v", err)
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var branch string
+		if err := rows.Scan(&branch); err != nil {
+			return nil, fmt.Errorf("failed to scan branch name: %v", err)
+		}
+		ids = append(ids, strings.TrimPrefix(branch, "game_"))
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating branches: %v", err)
+	}
+
+	return ids, nil
+}
+
+// quoteIdentifier quotes a database name for use in a SQL statement
+func quoteIdentifier(name string) string {
+	return "`" + strings.ReplaceAll(name, "`", "``") + "`"
 }
 
 // Close performs any necessary cleanup
 func (d *Database) Close() error {
+	for team, db := range d.fleets {
+		db.Close()
+		delete(d.fleets, team)
+	}
 	if d.db != nil {
 		return d.db.Close()
 	}
@@ -77,51 +262,165 @@ func (d *Database) GetTables() ([]string, error) {
 	return tables, nil
 }
 
-// Initialize creates the necessary tables in the database
-func (d *Database) Initialize() error {
-	if err := d.CreateBoardStatesTable(); err != nil {
+// Created returns the time the game was started
+func (d *Database) Created() (time.Time, error) {
+	var created time.Time
+	err := d.db.QueryRow("SELECT date FROM dolt_log WHERE message = ? ORDER BY date LIMIT 1", initializeMessage).Scan(&created)
+	if err == sql.ErrNoRows {
+		return time.Time{}, ErrNotStarted
+	}
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to query game log: %v", err)
+	}
+	return created, nil
+}
+
+// Initialize creates the necessary tables in the database and stores the game settings
+func (d *Database) Initialize(settings game.Settings) error {
+	if err := settings.Validate(); err != nil {
+		return fmt.Errorf("invalid game settings: %v", err)
+	}
+
+	// The board_states triggers read game_state, so it is created first
+	if err := d.CreateGameStateTable(); err != nil {
+		return fmt.Errorf("failed to initialize database: %v", err)
+	}
+	if err := d.CreateSettingsTable(settings.Width, settings.Height); err != nil {
+		return fmt.Errorf("failed to initialize database: %v", err)
+	}
+	if err := d.CreateBoardStatesTable(settings.Width, settings.Height); err != nil {
+		return fmt.Errorf("failed to initialize database: %v", err)
+	}
+	if err := d.CreateShipsTable(settings.Width, settings.Height); err != nil {
 		return fmt.Errorf("failed to initialize database: %v", err)
 	}
 	if err := d.CreateCoinTable(); err != nil {
 		return fmt.Errorf("failed to initialize database: %v", err)
 	}
+	if err := d.CreateFleetTable(settings.Fleet); err != nil {
+		return fmt.Errorf("failed to initialize database: %v", err)
+	}
 
 	// Commit the current state to Dolt
-	commitMessage := "Create board_states and coin tables"
-	_, err := d.db.Exec("CALL DOLT_COMMIT('-A', '-m', ?)", commitMessage)
+	_, err := d.db.Exec("CALL DOLT_COMMIT('-A', '-m', ?)", initializeMessage)
 	if err != nil {
 		log.Fatalf("Failed to commit to Dolt: %v", err)
 	}
 
+	// Each team places its fleet on a private branch made from the new game
+	for _, team := range game.Teams {
+		branch := privateBranchName(d.gameID, team)
+		if _, err := d.db.Exec("CALL DOLT_BRANCH('-f', ?)", branch); err != nil {
+			return fmt.Errorf("failed to create branch %s: %v", branch, err)
+		}
+	}
+
 	return nil
 }
 
-// CreateBoardStatesTable creates the board states table with the required schema
-func (d *Database) CreateBoardStatesTable() error {
-	query := `
+// CreateBoardStatesTable creates the board states table with the required schema. CHECK
+// constraints keep every cell on a board of the given size, shot boards to hits and misses
+// and ship boards to ship segments and hits. Triggers reject shots once the game is over,
+// name the cell in a second shot at it rather than leaving a bare duplicate key error, and
+// reject any change to a recorded shot, so even a raw SQL client cannot corrupt the boards.
+// The game_state table must already exist.
+func (d *Database) CreateBoardStatesTable(width, height int) error {
+	query := fmt.Sprintf(`
 		CREATE TABLE board_states (
 			x INT NOT NULL,
 			y INT NOT NULL,
 			board ENUM('red_ships', 'blue_ships', 'red_shots', 'blue_shots') NOT NULL,
 			state ENUM('H', 'M', 'S') NOT NULL,
-			PRIMARY KEY (x, y, board)
+			ship VARCHAR(32),
+			PRIMARY KEY (x, y, board),
+			CONSTRAINT board_states_on_board CHECK (x >= 0 AND x < %d AND y >= 0 AND y < %d),
+			CONSTRAINT board_states_state CHECK (
+				(board IN ('red_shots', 'blue_shots') AND state IN ('H', 'M')) OR
+				(board IN ('red_ships', 'blue_ships') AND state IN ('S', 'H'))
+			)
 		);
-	`
+	`, width, height)
 
 	_, err := d.db.Exec(query)
 	if err != nil {
 		return fmt.Errorf("failed to create board_states table: %v", err)
 	}
 
+	triggers := []string{
+		`CREATE TRIGGER board_states_record_shot BEFORE INSERT ON board_states
+		FOR EACH ROW
+		BEGIN
+			IF NEW.board IN ('red_shots', 'blue_shots') THEN
+				IF (SELECT status FROM game_state WHERE id = 1) <> 'in_progress' THEN
+					SIGNAL SQLSTATE '45000' SET MESSAGE_TEXT = 'the game is over';
+				ELSEIF EXISTS (
+					SELECT 1 FROM board_states WHERE x = NEW.x AND y = NEW.y AND board = NEW.board
+				) THEN
+					SIGNAL SQLSTATE '45000' SET MESSAGE_TEXT = 'that cell has already been shot';
+				END IF;
+			END IF;
+		END`,
+		`CREATE TRIGGER board_states_keep_shots BEFORE UPDATE ON board_states
+		FOR EACH ROW
+		BEGIN
+			IF OLD.board IN ('red_shots', 'blue_shots') OR NEW.board IN ('red_shots', 'blue_shots') THEN
+				SIGNAL SQLSTATE '45000' SET MESSAGE_TEXT = 'recorded shots cannot be changed';
+			END IF;
+		END`,
+		`CREATE TRIGGER board_states_keep_shots_on_delete BEFORE DELETE ON board_states
+		FOR EACH ROW
+		BEGIN
+			IF OLD.board IN ('red_shots', 'blue_shots') THEN
+				SIGNAL SQLSTATE '45000' SET MESSAGE_TEXT = 'recorded shots cannot be removed';
+			END IF;
+		END`,
+	}
+	for _, trigger := range triggers {
+		if _, err := d.db.Exec(trigger); err != nil {
+			return fmt.Errorf("failed to create board_states trigger: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// CreateShipsTable creates the ships table which records the identity and placement of
+// every ship. A CHECK constraint keeps each ship entirely on a board of the given size.
+func (d *Database) CreateShipsTable(width, height int) error {
+	query := fmt.Sprintf(`
+		CREATE TABLE ships (
+			board ENUM('red_ships', 'blue_ships') NOT NULL,
+			name VARCHAR(32) NOT NULL,
+			x INT NOT NULL,
+			y INT NOT NULL,
+			length INT NOT NULL,
+			vertical BOOLEAN NOT NULL,
+			PRIMARY KEY (board, name),
+			CONSTRAINT ships_on_board CHECK (
+				length > 0 AND x >= 0 AND y >= 0 AND
+				((vertical AND x < %[1]d AND y + length <= %[2]d) OR (NOT vertical AND x + length <= %[1]d AND y < %[2]d))
+			)
+		);
+	`, width, height)
+
+	_, err := d.db.Exec(query)
+	if err != nil {
+		return fmt.Errorf("failed to create ships table: %v", err)
+	}
+
 	return nil
 }
 
-// CreateCoinTable creates the coin table with the required schema
+// CreateCoinTable creates the coin table which records each team joining the game and the
+// commitment to its fleet layout. The salt of the commitment is only recorded on the team's
+// private branch until the game ends.
 func (d *Database) CreateCoinTable() error {
 	query := `
 		CREATE TABLE coin (
 			team ENUM('red', 'blue') PRIMARY KEY,
-			flip FLOAT NOT NULL
+			joined_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			commitment CHAR(64),
+			salt CHAR(32)
 		);
 	`
 
@@ -133,180 +432,1321 @@ func (d *Database) CreateCoinTable() error {
 	return nil
 }
 
-// Direction represents the orientation of a ship
-type Direction bool
+// CreateGameStateTable creates the single-row game_state table and marks the game as in progress.
+// The table also tracks the coin toss result, whose turn it is, how many moves have been made
+// any pending request to take back a move, which team resigned, if one did, and whether the
+// fleets have been revealed.
+func (d *Database) CreateGameStateTable() error {
+	query := `
+		CREATE TABLE game_state (
+			id INT PRIMARY KEY,
+			status ENUM('in_progress', 'red_won', 'blue_won') NOT NULL,
+			first_team ENUM('red', 'blue'),
+			current_team ENUM('red', 'blue'),
+			move INT NOT NULL DEFAULT 0,
+			takeback ENUM('red', 'blue'),
+			resigned ENUM('red', 'blue'),
+			revealed BOOLEAN NOT NULL DEFAULT FALSE
+		);
+	`
 
-const (
-	Vertical   Direction = true
-	Horizontal Direction = false
-)
+	_, err := d.db.Exec(query)
+	if err != nil {
+		return fmt.Errorf("failed to create game_state table: %v", err)
+	}
 
-// InsertShip inserts a ship into the database at the given position with the given length and direction
-func (d *Database) InsertShip(board string, x, y int, length int, direction Direction) error {
-	// Validate board type
-	if board != "red_ships" && board != "blue_ships" {
-		return fmt.Errorf("invalid board type: %s", board)
+	_, err = d.db.Exec("INSERT INTO game_state (id, status) VALUES (1, ?)", game.StatusInProgress)
+	if err != nil {
+		return fmt.Errorf("failed to insert game state: %v", err)
 	}
 
-	// Validate coordinates
-	if x < 0 || x > 9 || y < 0 || y > 9 {
-		return fmt.Errorf("coordinates out of bounds: (%d, %d)", x, y)
+	return nil
+}
+
+// CreateSettingsTable creates the single-row settings table holding the board dimensions
+func (d *Database) CreateSettingsTable(width, height int) error {
+	query := `
+		CREATE TABLE settings (
+			id INT PRIMARY KEY,
+			width INT NOT NULL,
+			height INT NOT NULL
+		);
+	`
+
+	_, err := d.db.Exec(query)
+	if err != nil {
+		return fmt.Errorf("failed to create settings table: %v", err)
 	}
 
-	// Validate length
-	if length < 2 || length > 5 {
-		return fmt.Errorf("invalid ship length: %d", length)
+	_, err = d.db.Exec("INSERT INTO settings (id, width, height) VALUES (1, ?, ?)", width, height)
+	if err != nil {
+		return fmt.Errorf("failed to insert settings: %v", err)
 	}
 
-	// Check if ship fits on board based on direction
-	switch direction {
-	case Vertical:
-		if y+length > 9 {
-			return fmt.Errorf("ship too long to fit at position: (%d, %d) pointing north", x, y)
-		}
-	case Horizontal:
-		if x+length > 9 {
-			return fmt.Errorf("ship too long to fit at position: (%d, %d) pointing south", x, y)
-		}
+	return nil
+}
+
+// GetSettings returns the board dimensions and fleet chosen when the game was started
+func (d *Database) GetSettings() (game.Settings, error) {
+	return loadSettings(d.db, "")
+}
+
+// loadSettings reads the board dimensions and the fleet
+func loadSettings(q querier, revision string) (game.Settings, error) {
+	var settings game.Settings
+	query := "SELECT width, height FROM " + tableAt("settings", revision) + " WHERE id = 1"
+	err := q.QueryRow(query).Scan(&settings.Width, &settings.Height)
+	if err != nil {
+		return game.Settings{}, fmt.Errorf("failed to query board size: %v", err)
+	}
+	settings.Fleet, err = loadFleet(q, revision)
+	if err != nil {
+		return game.Settings{}, err
 	}
+	return settings, nil
+}
 
-	// Insert each segment of the ship
-	for i := 0; i < length; i++ {
-		var newX, newY int
-		switch direction {
-		case Vertical:
-			newX, newY = x, y+i
-		case Horizontal:
-			newX, newY = x+i, y
-		}
+// CreateFleetTable creates the fleet table and fills it with the ships each team must place
+func (d *Database) CreateFleetTable(fleet game.Fleet) error {
+	query := `
+		CREATE TABLE fleet (
+			position INT PRIMARY KEY,
+			name VARCHAR(32) NOT NULL UNIQUE,
+			length INT NOT NULL
+		);
+	`
 
-		query := `
-			INSERT INTO board_states (x, y, board, state)
-			VALUES (?, ?, ?, 'S')
-		`
-		_, err := d.db.Exec(query, newX, newY, board)
+	_, err := d.db.Exec(query)
+	if err != nil {
+		return fmt.Errorf("failed to create fleet table: %v", err)
+	}
+
+	for i, ship := range fleet {
+		_, err := d.db.Exec("INSERT INTO fleet (position, name, length) VALUES (?, ?, ?)", i, ship.Name, ship.Length)
 		if err != nil {
-			return fmt.Errorf("failed to insert ship segment: %v", err)
+			return fmt.Errorf("failed to insert %s into fleet: %v", ship.Name, err)
 		}
 	}
 
 	return nil
 }
 
-// Query executes a query that returns rows
-func (d *Database) Query(query string, args ...interface{}) (*sql.Rows, error) {
-	return d.db.Query(query, args...)
+// GetFleet returns the ships each team must place in this game
+func (d *Database) GetFleet() (game.Fleet, error) {
+	return loadFleet(d.db, "")
 }
 
-// Exec executes a query without returning rows
-func (d *Database) Exec(query string, args ...interface{}) (sql.Result, error) {
-	return d.db.Exec(query, args...)
+// loadFleet reads the fleet in the order it was defined
+func loadFleet(q querier, revision string) (game.Fleet, error) {
+	rows, err := q.Query("SELECT name, length FROM " + tableAt("fleet", revision) + " ORDER BY position")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query fleet: %v", err)
+	}
+	defer rows.Close()
+
+	var fleet game.Fleet
+	for rows.Next() {
+		var ship game.Ship
+		if err := rows.Scan(&ship.Name, &ship.Length); err != nil {
+			return nil, fmt.Errorf("failed to scan ship: %v", err)
+		}
+		fleet = append(fleet, ship)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating fleet: %v", err)
+	}
+
+	return fleet, nil
 }
 
-// QueryRow executes a query that is expected to return at most one row
-func (d *Database) QueryRow(query string, args ...interface{}) *sql.Row {
-	return d.db.QueryRow(query, args...)
+// LoadGame reads the shared state of the game, which holds neither fleet
+func (d *Database) LoadGame() (*game.Game, error) {
+	return loadGame(d.db, "")
 }
 
-// InsertCoin inserts a random number for a team in the coin table
-func (d *Database) InsertCoin(team string) error {
-	query := `
-		INSERT INTO coin (team, flip)
-		VALUES (?, RAND())
-	`
-	_, err := d.db.Exec(query, team)
+// LoadGameFor reads the shared state of the game and the fleet of a team from its private branch
+func (d *Database) LoadGameFor(team string) (*game.Game, error) {
+	if team == "" {
+		return d.LoadGame()
+	}
+
+	g, err := loadGame(d.db, "")
 	if err != nil {
-		return fmt.Errorf("failed to insert coin: %v", err)
+		return nil, err
 	}
-	return nil
+	if err := d.loadPrivate(g, team); err != nil {
+		return nil, err
+	}
+	return g, nil
 }
 
-// ProcessShot handles the logic for taking a shot at a position
-func (d *Database) ProcessShot(shotBoard, targetBoard string, x, y int) error {
-	query := `
-		INSERT INTO board_states (x, y, board, state)
-		VALUES (?, ?, ?, 'M')
-	`
-	_, err := d.db.Exec(query, x, y, shotBoard)
+// refereeGame reads the shared state of the game using q and both fleets from the
+// teams' private branches, giving the complete game needed to resolve shots
+func (d *Database) refereeGame(q querier) (*game.Game, error) {
+	g, err := loadGame(q, "")
 	if err != nil {
-		return fmt.Errorf("failed to insert miss: %v", err)
+		return nil, err
+	}
+	for _, team := range game.Teams {
+		if err := d.loadPrivate(g, team); err != nil {
+			return nil, err
+		}
 	}
+	return g, nil
+}
 
-	query = `
-		UPDATE board_states rs
-		JOIN board_states bs ON rs.x = bs.x AND rs.y = bs.y
-		SET rs.state = 'H',
-			bs.state = 'H'
-		WHERE rs.board = ? 
-		AND bs.board = ?
-		AND rs.x = ? AND rs.y = ?
-		AND bs.state = 'S'
-	`
-	_, err = d.db.Exec(query, shotBoard, targetBoard, x, y)
+// loadPrivate adds a team's fleet and commitment salt from its private branch to g,
+// unless they have already been revealed on the shared branch
+func (d *Database) loadPrivate(g *game.Game, team string) error {
+	return d.loadPrivateAt(g, team, "")
+}
+
+// loadPrivateAt is loadPrivate as of a revision of the team's private branch, or from its
+// working set if revision is empty
+func (d *Database) loadPrivateAt(g *game.Game, team, revision string) error {
+	fleet, err := d.fleetDB(team)
 	if err != nil {
-		return fmt.Errorf("failed to process shot: %v", err)
+		return err
+	}
+	if g.Revealed {
+		return nil
+	}
+
+	if err := loadShips(fleet, revision, g); err != nil {
+		return err
 	}
 
+	var salt sql.NullString
+	err = fleet.QueryRow("SELECT salt FROM "+tableAt("coin", revision)+" WHERE team = ?", team).Scan(&salt)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to query %s commitment salt: %v", team, err)
+	}
+	if c, ok := g.Commitments[team]; ok {
+		c.Salt = salt.String
+		g.Commitments[team] = c
+	}
 	return nil
 }
 
-// PlaceRandomShips places all ships randomly on the board for a team
-func (d *Database) PlaceRandomShips(team string) error {
-	ships := []struct {
-		length int
-	}{
-		{5}, // Carrier
-		{4}, // Battleship
-		{3}, // Cruiser
-		{3}, // Submarine
-		{2}, // Destroyer
+// LoadGameAt reads the shared state of the game as it was at the given commit
+func (d *Database) LoadGameAt(hash string) (*game.Game, error) {
+	return loadGame(d.db, hash)
+}
+
+// LoadGameForAt reads the shared state of the game at one of its commits and the fleet of
+// a team as last committed on its private branch by the time of that commit
+func (d *Database) LoadGameForAt(team, hash string) (*game.Game, error) {
+	g, err := loadGame(d.db, hash)
+	if err != nil {
+		return nil, err
 	}
 
-	board := fmt.Sprintf("%s_ships", team)
-	for _, ship := range ships {
-		for {
-			// Generate random position and direction
-			x := rand.Intn(10)
-			y := rand.Intn(10)
-			direction := Direction(rand.Float32() < 0.5)
-
-			// Check if ship fits on board
-			if direction == Vertical && y+ship.length > 9 {
-				continue
-			}
-			if direction == Horizontal && x+ship.length > 9 {
-				continue
-			}
+	var date time.Time
+	if err := d.db.QueryRow("SELECT date FROM dolt_log WHERE commit_hash = ?", hash).Scan(&date); err != nil {
+		return nil, fmt.Errorf("failed to query date of commit %s: %v", hash, err)
+	}
+	fleet, err := d.fleetDB(team)
+	if err != nil {
+		return nil, err
+	}
+	var private string
+	err = fleet.QueryRow("SELECT commit_hash FROM dolt_log WHERE date <= ? ORDER BY date DESC LIMIT 1", date).Scan(&private)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find %s fleet at commit %s: %v", team, hash, err)
+	}
 
-			// Check if position is already occupied
-			occupied := false
-			for i := 0; i < ship.length; i++ {
-				var count int
-				checkX, checkY := x, y
-				if direction == Vertical {
-					checkY += i
-				} else {
-					checkX += i
-				}
-				err := d.db.QueryRow("SELECT COUNT(*) FROM board_states WHERE board = ? AND x = ? AND y = ?", board, checkX, checkY).Scan(&count)
-				if err != nil {
-					return fmt.Errorf("failed to check position: %v", err)
-				}
-				if count > 0 {
-					occupied = true
-					break
-				}
-			}
+	if err := d.loadPrivateAt(g, team, private); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
 
-			if !occupied {
-				err := d.InsertShip(board, x, y, ship.length, direction)
-				if err != nil {
-					return fmt.Errorf("failed to insert ship: %v", err)
-				}
-				break
-			}
+// loadGame reads the settings, joined teams, turn, any ship placements and shots into a Game,
+// as of a revision such as a commit hash or from the working set if revision is empty.
+// Each table is read to completion before the next is queried, so it is safe inside a transaction.
+func loadGame(q querier, revision string) (*game.Game, error) {
+	settings, err := loadSettings(q, revision)
+	if err != nil {
+		return nil, err
+	}
+	g := game.New(settings)
+
+	if err := loadJoined(q, revision, g); err != nil {
+		return nil, err
+	}
+	if err := loadState(q, revision, g); err != nil {
+		return nil, err
+	}
+	if err := loadShips(q, revision, g); err != nil {
+		return nil, err
+	}
+	if err := loadShots(q, revision, g); err != nil {
+		return nil, err
+	}
+
+	return g, nil
+}
+
+// History returns the commits made on the game's branch since the game was started, oldest first
+func (d *Database) History() ([]Commit, error) {
+	return history(d.db)
+}
+
+// history returns the commits made on the branch q is connected to since the game was
+// started, oldest first
+func history(q querier) ([]Commit, error) {
+	rows, err := q.Query("SELECT commit_hash, message, date FROM dolt_log")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query game log: %v", err)
+	}
+	defer rows.Close()
+
+	// dolt_log lists the newest commit first and continues past the start of the game
+	// into the history of the branch it was created from
+	var commits []Commit
+	started := false
+	for rows.Next() {
+		var c Commit
+		if err := rows.Scan(&c.Hash, &c.Message, &c.Date); err != nil {
+			return nil, fmt.Errorf("failed to scan commit: %v", err)
+		}
+		commits = append(commits, c)
+		if c.Message == initializeMessage {
+			started = true
+			break
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating game log: %v", err)
+	}
+	if !started {
+		return nil, ErrNotStarted
+	}
+
+	for i, j := 0, len(commits)-1; i < j; i, j = i+1, j-1 {
+		commits[i], commits[j] = commits[j], commits[i]
+	}
+	return commits, nil
+}
+
+// Moves returns every committed move by combining the game log with the shots each
+// commit added to board_states
+func (d *Database) Moves() ([]Move, error) {
+	history, err := d.History()
+	if err != nil {
+		return nil, err
+	}
+	commits := make(map[string]int, len(history))
+	for i, c := range history {
+		commits[c.Hash] = i
+	}
+
+	query := `
+		SELECT to_x, to_y, to_board, to_state, to_ship, to_commit
+		FROM dolt_diff_board_states
+		WHERE diff_type = 'added' AND to_board IN ('red_shots', 'blue_shots')
+	`
+	rows, err := d.db.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query board_states diff: %v", err)
+	}
+	defer rows.Close()
+
+	var moves []Move
+	for rows.Next() {
+		var board, state, hash string
+		var sunk sql.NullString
+		var move Move
+		if err := rows.Scan(&move.Target.X, &move.Target.Y, &board, &state, &sunk, &hash); err != nil {
+			return nil, fmt.Errorf("failed to scan shot: %v", err)
+		}
+
+		// Skip shots that have not been committed yet
+		i, ok := commits[hash]
+		if !ok {
+			continue
 		}
+		move.Team = strings.TrimSuffix(board, "_shots")
+		move.Hit = state == "H"
+		move.Sunk = sunk.String
+		move.Hash, move.Date = hash, history[i].Date
+		moves = append(moves, move)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating board_states diff: %v", err)
 	}
 
+	sort.Slice(moves, func(i, j int) bool {
+		return commits[moves[i].Hash] < commits[moves[j].Hash]
+	})
+
+	g, err := d.LoadGame()
+	if err != nil {
+		return nil, err
+	}
+	return numberMoves(moves, g), nil
+}
+
+// numberMoves drops the moves taken back or reverted since, numbers the rest in order and
+// marks the last one as winning if g was won by it. A move that was undone is no longer
+// among the shots of g, or its cell was shot at again by a later move.
+func numberMoves(moves []Move, g *game.Game) []Move {
+	var kept []Move
+	fired := make(map[string]bool)
+	for i := len(moves) - 1; i >= 0; i-- {
+		key := moves[i].Team + " " + moves[i].Target.String()
+		if _, ok := g.Shots[moves[i].Team][moves[i].Target]; !ok || fired[key] {
+			continue
+		}
+		fired[key] = true
+		kept = append([]Move{moves[i]}, kept...)
+	}
+	moves = kept
+
+	for i := range moves {
+		moves[i].Move = i + 1
+	}
+	if len(moves) > 0 && g.Over() && g.Resigned == "" {
+		moves[len(moves)-1].Winner = g.Winner
+	}
+	return moves
+}
+
+// tableAt refers to a table as of a revision, or to its working state if revision is empty
+func tableAt(table, revision string) string {
+	if revision == "" {
+		return table
+	}
+	return fmt.Sprintf("%s AS OF '%s'", table, strings.ReplaceAll(revision, "'", "''"))
+}
+
+// loadJoined marks the teams recorded in the coin table as joined and reads their
+// commitments to their fleet layouts
+func loadJoined(q querier, revision string, g *game.Game) error {
+	rows, err := q.Query("SELECT team, commitment, salt FROM " + tableAt("coin", revision))
+	if err != nil {
+		return fmt.Errorf("failed to query coin table: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var team string
+		var commitment, salt sql.NullString
+		if err := rows.Scan(&team, &commitment, &salt); err != nil {
+			return fmt.Errorf("failed to scan team: %v", err)
+		}
+		g.Joined[team] = true
+		if commitment.Valid {
+			g.Commitments[team] = game.Commitment{Hash: commitment.String, Salt: salt.String}
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error iterating teams: %v", err)
+	}
+
+	return nil
+}
+
+// loadState reads the coin toss, turn, move count, winner and whether the fleets have been
+// revealed from the game_state table
+func loadState(q querier, revision string, g *game.Game) error {
+	var status string
+	var first, current sql.NullString
+	var takeback, resigned sql.NullString
+	query := "SELECT status, first_team, current_team, move, takeback, resigned, revealed FROM " + tableAt("game_state", revision) + " WHERE id = 1"
+	if err := q.QueryRow(query).Scan(&status, &first, &current, &g.Move, &takeback, &resigned, &g.Revealed); err != nil {
+		return fmt.Errorf("failed to query game state: %v", err)
+	}
+
+	g.FirstTeam, g.Turn, g.Takeback, g.Resigned = first.String, current.String, takeback.String, resigned.String
+	switch status {
+	case game.StatusRedWon:
+		g.Winner = game.Red
+	case game.StatusBlueWon:
+		g.Winner = game.Blue
+	}
+
+	return nil
+}
+
+// loadShips places the ships recorded in the ships table on each team's board. Only a
+// team's private branch records its ships.
+func loadShips(q querier, revision string, g *game.Game) error {
+	rows, err := q.Query("SELECT board, name, x, y, length, vertical FROM " + tableAt("ships", revision))
+	if err != nil {
+		return fmt.Errorf("failed to query ships: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var board string
+		var ship game.Ship
+		var vertical bool
+		if err := rows.Scan(&board, &ship.Name, &ship.Bow.X, &ship.Bow.Y, &ship.Length, &vertical); err != nil {
+			return fmt.Errorf("failed to scan ship: %v", err)
+		}
+		ship.Direction = game.Direction(vertical)
+
+		team := strings.TrimSuffix(board, "_ships")
+		g.Boards[team].Ships = append(g.Boards[team].Ships, ship)
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error iterating ships: %v", err)
+	}
+
+	return nil
+}
+
+// loadShots reads the shots fired by each team. The ship column of a shot names the ship it sunk.
+func loadShots(q querier, revision string, g *game.Game) error {
+	query := "SELECT x, y, board, state, ship FROM " + tableAt("board_states", revision) + " WHERE board IN ('red_shots', 'blue_shots')"
+	rows, err := q.Query(query)
+	if err != nil {
+		return fmt.Errorf("failed to query shots: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var board, state string
+		var sunk sql.NullString
+		var shot game.Shot
+		if err := rows.Scan(&shot.Target.X, &shot.Target.Y, &board, &state, &sunk); err != nil {
+			return fmt.Errorf("failed to scan shot: %v", err)
+		}
+		shot.Team = strings.TrimSuffix(board, "_shots")
+		shot.Hit = state == "H"
+		shot.Sunk = sunk.String
+
+		g.Shots[shot.Team][shot.Target] = shot
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error iterating shots: %v", err)
+	}
+
+	return nil
+}
+
+// saveState writes the coin toss, turn, move count, takeback request, resignation, reveal and status of g to the game_state table
+func saveState(q querier, g *game.Game) error {
+	query := `
+		UPDATE game_state
+		SET status = ?, first_team = ?, current_team = ?, move = ?, takeback = ?, resigned = ?, revealed = ?
+		WHERE id = 1
+	`
+	_, err := q.Exec(query, g.Status(), nullString(g.FirstTeam), nullString(g.Turn), g.Move, nullString(g.Takeback), nullString(g.Resigned), g.Revealed)
+	if err != nil {
+		return fmt.Errorf("failed to update game state: %v", err)
+	}
+	return nil
+}
+
+// nullString converts an empty string to SQL NULL
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
+// boardTeam returns the team owning a ships board such as red_ships
+func boardTeam(board string) (string, error) {
+	if board != "red_ships" && board != "blue_ships" {
+		return "", fmt.Errorf("invalid board type: %s", board)
+	}
+	return strings.TrimSuffix(board, "_ships"), nil
+}
+
+// InsertShip inserts a named ship on its team's private branch at the given position with
+// the given length and direction
+func (d *Database) InsertShip(board, name string, x, y int, length int, direction game.Direction) error {
+	team, err := boardTeam(board)
+	if err != nil {
+		return err
+	}
+
+	g, err := d.LoadGameFor(team)
+	if err != nil {
+		return err
+	}
+
+	ship := game.Ship{Name: name, Length: length, Bow: game.Coordinate{X: x, Y: y}, Direction: direction}
+	if err := g.PlaceShip(team, ship); err != nil {
+		return err
+	}
+	fleet, err := d.fleetDB(team)
+	if err != nil {
+		return err
+	}
+	return insertShip(fleet, team, ship)
+}
+
+// insertShip records a ship and each of its segments
+func insertShip(q querier, team string, ship game.Ship) error {
+	board := fmt.Sprintf("%s_ships", team)
+
+	// Record the ship itself so its cells can be tied back to it
+	query := `
+		INSERT INTO ships (board, name, x, y, length, vertical)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`
+	_, err := q.Exec(query, board, ship.Name, ship.Bow.X, ship.Bow.Y, ship.Length, bool(ship.Direction))
+	if err != nil {
+		return fmt.Errorf("failed to insert ship %s: %v", ship.Name, err)
+	}
+
+	// Insert each segment of the ship
+	for _, cell := range ship.Cells() {
+		query := `
+			INSERT INTO board_states (x, y, board, state, ship)
+			VALUES (?, ?, ?, 'S', ?)
+		`
+		_, err := q.Exec(query, cell.X, cell.Y, board, ship.Name)
+		if err != nil {
+			return fmt.Errorf("failed to insert ship segment: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// ClearShips removes every ship from the given board
+func (d *Database) ClearShips(board string) error {
+	team, err := boardTeam(board)
+	if err != nil {
+		return err
+	}
+	fleet, err := d.fleetDB(team)
+	if err != nil {
+		return err
+	}
+
+	if _, err := fleet.Exec("DELETE FROM board_states WHERE board = ?", board); err != nil {
+		return fmt.Errorf("failed to clear board: %v", err)
+	}
+	if _, err := fleet.Exec("DELETE FROM ships WHERE board = ?", board); err != nil {
+		return fmt.Errorf("failed to clear ships: %v", err)
+	}
+	return nil
+}
+
+// CommitLayout publishes a salted hash of a team's fleet layout on the shared branch. The
+// salt is recorded only on the team's private branch until the game ends.
+func (d *Database) CommitLayout(team string) error {
+	g, err := d.LoadGameFor(team)
+	if err != nil {
+		return err
+	}
+	if err := g.CommitLayout(team); err != nil {
+		return err
+	}
+	commitment := g.Commitments[team]
+
+	fleet, err := d.fleetDB(team)
+	if err != nil {
+		return err
+	}
+	_, err = fleet.Exec("REPLACE INTO coin (team, commitment, salt) VALUES (?, ?, ?)", team, commitment.Hash, commitment.Salt)
+	if err != nil {
+		return fmt.Errorf("failed to record %s commitment salt: %v", team, err)
+	}
+	if _, err := d.db.Exec("UPDATE coin SET commitment = ? WHERE team = ?", commitment.Hash, team); err != nil {
+		return fmt.Errorf("failed to publish %s commitment: %v", team, err)
+	}
+	return nil
+}
+
+// reveal copies both fleets and the salts of their commitments from the private branches
+// to the shared branch once the game is over, and commits them
+func (d *Database) reveal() error {
+	g, err := d.refereeGame(d.db)
+	if err != nil {
+		return err
+	}
+	if err := g.Reveal(); err != nil {
+		return err
+	}
+
+	for _, team := range game.Teams {
+		board := fmt.Sprintf("%s_ships", team)
+		for _, ship := range g.Boards[team].Ships {
+			query := `
+				INSERT INTO ships (board, name, x, y, length, vertical)
+				VALUES (?, ?, ?, ?, ?, ?)
+			`
+			_, err := d.db.Exec(query, board, ship.Name, ship.Bow.X, ship.Bow.Y, ship.Length, bool(ship.Direction))
+			if err != nil {
+				return fmt.Errorf("failed to reveal ship %s: %v", ship.Name, err)
+			}
+		}
+		if _, err := d.db.Exec("UPDATE coin SET salt = ? WHERE team = ?", nullString(g.Commitments[team].Salt), team); err != nil {
+			return fmt.Errorf("failed to reveal %s commitment salt: %v", team, err)
+		}
+	}
+	if err := saveState(d.db, g); err != nil {
+		return err
+	}
+	return d.Commit(revealMessage)
+}
+
+// RecordVerdict records the referee's verdict on a commit in the referee table, which is
+// created the first time a verdict is recorded, and commits only that table
+func (d *Database) RecordVerdict(v Verdict) error {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		CREATE TABLE IF NOT EXISTS referee (
+			id INT AUTO_INCREMENT PRIMARY KEY,
+			commit_hash VARCHAR(64) NOT NULL UNIQUE,
+			message TEXT NOT NULL,
+			problems TEXT,
+			reverted BOOLEAN NOT NULL,
+			checked_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+		);
+	`
+	if _, err := tx.Exec(query); err != nil {
+		return fmt.Errorf("failed to create referee table: %v", err)
+	}
+
+	query = `
+		REPLACE INTO referee (commit_hash, message, problems, reverted)
+		VALUES (?, ?, ?, ?)
+	`
+	_, err = tx.Exec(query, v.Hash, v.Message, nullString(strings.Join(v.Problems, "\n")), v.Reverted)
+	if err != nil {
+		return fmt.Errorf("failed to record verdict: %v", err)
+	}
+
+	// Players' uncommitted changes are left out of the referee's commit
+	if _, err := tx.Exec("CALL DOLT_ADD('referee')"); err != nil {
+		return fmt.Errorf("failed to stage verdict: %v", err)
+	}
+	if _, err := tx.Exec("CALL DOLT_COMMIT('-m', ?)", verdictMessage(v)); err != nil {
+		return fmt.Errorf("failed to commit verdict: %v", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %v", err)
+	}
+	return nil
+}
+
+// Verdicts returns every verdict in the referee table, oldest first. A game that has never
+// been refereed has none.
+func (d *Database) Verdicts() ([]Verdict, error) {
+	tables, err := d.GetTables()
+	if err != nil {
+		return nil, err
+	}
+	refereed := false
+	for _, table := range tables {
+		refereed = refereed || table == "referee"
+	}
+	if !refereed {
+		return nil, nil
+	}
+
+	rows, err := d.db.Query("SELECT commit_hash, message, problems, reverted, checked_at FROM referee ORDER BY id")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query referee table: %v", err)
+	}
+	defer rows.Close()
+
+	var verdicts []Verdict
+	for rows.Next() {
+		var v Verdict
+		var problems sql.NullString
+		if err := rows.Scan(&v.Hash, &v.Message, &problems, &v.Reverted, &v.Date); err != nil {
+			return nil, fmt.Errorf("failed to scan verdict: %v", err)
+		}
+		if problems.Valid {
+			v.Problems = strings.Split(problems.String, "\n")
+		}
+		verdicts = append(verdicts, v)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating verdicts: %v", err)
+	}
+
+	return verdicts, nil
+}
+
+// Revert undoes the changes made by the latest commit with DOLT_REVERT and gives the new
+// commit the referee's message. A fleet moved on its private branch since the commit before
+// is put back first, or the shots judged against it would be reverted again and again.
+func (d *Database) Revert(hash string) error {
+	var head string
+	if err := d.db.QueryRow("SELECT commit_hash FROM dolt_log LIMIT 1").Scan(&head); err != nil {
+		return fmt.Errorf("failed to query game log: %v", err)
+	}
+	if head != hash {
+		return fmt.Errorf("only the latest commit can be reverted")
+	}
+
+	var parent string
+	if err := d.db.QueryRow("SELECT parent_hash FROM dolt_commit_ancestors WHERE commit_hash = ? LIMIT 1", hash).Scan(&parent); err != nil {
+		return fmt.Errorf("failed to query parent of commit %s: %v", hash, err)
+	}
+	for _, team := range game.Teams {
+		if err := d.restoreFleet(team, parent, revertMessage(hash)); err != nil {
+			return err
+		}
+	}
+
+	if _, err := d.db.Exec("CALL DOLT_REVERT(?)", hash); err != nil {
+		return fmt.Errorf("failed to revert commit %s: %v", hash, err)
+	}
+	if _, err := d.db.Exec("CALL DOLT_COMMIT('--amend', '-m', ?)", revertMessage(hash)); err != nil {
+		return fmt.Errorf("failed to commit revert of %s: %v", hash, err)
+	}
+	return nil
+}
+
+// restoreFleet puts a team's fleet back on its private branch as it was at a commit of the
+// shared branch, and commits it with the given message if that changed anything
+func (d *Database) restoreFleet(team, hash, message string) error {
+	g, err := d.LoadGameForAt(team, hash)
+	if err != nil {
+		return err
+	}
+	// Revealed fleets are on the shared branch, which the revert itself restores
+	if g.Revealed {
+		return nil
+	}
+
+	fleet, err := d.fleetDB(team)
+	if err != nil {
+		return err
+	}
+	if err := d.ClearShips(fmt.Sprintf("%s_ships", team)); err != nil {
+		return err
+	}
+	for _, ship := range g.Boards[team].Ships {
+		if err := insertShip(fleet, team, ship); err != nil {
+			return err
+		}
+	}
+
+	changed, err := uncommitted(fleet)
+	if err != nil {
+		return fmt.Errorf("failed to query %s fleet status: %v", team, err)
+	}
+	if !changed {
+		return nil
+	}
+	if _, err := fleet.Exec("CALL DOLT_COMMIT('-a', '-m', ?)", message); err != nil {
+		return fmt.Errorf("failed to commit restored %s fleet: %v", team, err)
+	}
+	return nil
+}
+
+// Audit walks the history of the game's shared branch and of both private branches and
+// returns every anomaly found in the commits and in the rows dolt_diff says they changed
+func (d *Database) Audit() ([]Anomaly, error) {
+	branches := []struct {
+		name   string
+		q      querier
+		shared bool
+	}{
+		{branchName(d.gameID), d.db, true},
+	}
+	for _, team := range game.Teams {
+		fleet, err := d.fleetDB(team)
+		if err != nil {
+			return nil, err
+		}
+		branches = append(branches, struct {
+			name   string
+			q      querier
+			shared bool
+		}{privateBranchName(d.gameID, team), fleet, false})
+	}
+
+	var anomalies []Anomaly
+	for _, branch := range branches {
+		commits, err := auditedCommits(branch.q)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read history of %s: %v", branch.name, err)
+		}
+		anomalies = append(anomalies, audit(branch.name, commits, branch.shared)...)
+	}
+	return anomalies, nil
+}
+
+// auditedCommits reads the history of the branch q is connected to, with the number of
+// parents of each commit from dolt_commit_ancestors and the rows it changed from dolt_diff
+func auditedCommits(q querier) ([]auditedCommit, error) {
+	history, err := history(q)
+	if err != nil {
+		return nil, err
+	}
+	commits := make([]auditedCommit, len(history))
+	index := make(map[string]int, len(history))
+	for i, c := range history {
+		commits[i].Commit = c
+		index[c.Hash] = i
+	}
+
+	if err := readParents(q, commits, index); err != nil {
+		return nil, err
+	}
+
+	diffs := []struct {
+		table string
+		query string
+	}{
+		{"ships", "SELECT to_commit, diff_type, COALESCE(to_board, from_board) FROM dolt_diff_ships"},
+		{"board_states", "SELECT to_commit, diff_type, COALESCE(to_board, from_board) FROM dolt_diff_board_states WHERE COALESCE(to_board, from_board) IN ('red_ships', 'blue_ships')"},
+		{"coin", "SELECT to_commit, diff_type, COALESCE(to_team, from_team) FROM dolt_diff_coin"},
+	}
+	for _, diff := range diffs {
+		if err := readChanges(q, diff.table, diff.query, commits, index); err != nil {
+			return nil, err
+		}
+	}
+	return commits, nil
+}
+
+// readParents counts the parents of each commit
+func readParents(q querier, commits []auditedCommit, index map[string]int) error {
+	rows, err := q.Query("SELECT commit_hash, COUNT(*) FROM dolt_commit_ancestors GROUP BY commit_hash")
+	if err != nil {
+		return fmt.Errorf("failed to query commit ancestors: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var hash string
+		var parents int
+		if err := rows.Scan(&hash, &parents); err != nil {
+			return fmt.Errorf("failed to scan commit ancestors: %v", err)
+		}
+		if i, ok := index[hash]; ok {
+			commits[i].parents = parents
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error iterating commit ancestors: %v", err)
+	}
+
+	return nil
+}
+
+// readChanges adds the rows of a table changed by each commit, read with a query returning
+// the commit, the type of change and the row's board or team. Uncommitted changes and
+// commits from before the game are skipped.
+func readChanges(q querier, table, query string, commits []auditedCommit, index map[string]int) error {
+	rows, err := q.Query(query)
+	if err != nil {
+		return fmt.Errorf("failed to query %s diff: %v", table, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var hash string
+		ch := change{table: table}
+		if err := rows.Scan(&hash, &ch.diffType, &ch.key); err != nil {
+			return fmt.Errorf("failed to scan %s diff: %v", table, err)
+		}
+		if i, ok := index[hash]; ok {
+			commits[i].changes = append(commits[i].changes, ch)
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error iterating %s diff: %v", table, err)
+	}
+
+	return nil
+}
+
+// Query executes a query that returns rows
+func (d *Database) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	return d.db.Query(query, args...)
+}
+
+// Exec executes a query without returning rows
+func (d *Database) Exec(query string, args ...interface{}) (sql.Result, error) {
+	return d.db.Exec(query, args...)
+}
+
+// QueryRow executes a query that is expected to return at most one row
+func (d *Database) QueryRow(query string, args ...interface{}) *sql.Row {
+	return d.db.QueryRow(query, args...)
+}
+
+// Commit records every change made since the last commit as a Dolt commit with the given
+// message, on the shared branch and on any private branch whose fleet has changed. A
+// branch with nothing to commit is left alone.
+func (d *Database) Commit(message string) error {
+	for team, fleet := range d.fleets {
+		changed, err := uncommitted(fleet)
+		if err != nil {
+			return fmt.Errorf("failed to query %s fleet status: %v", team, err)
+		}
+		if !changed {
+			continue
+		}
+		if _, err := fleet.Exec("CALL DOLT_COMMIT('-a', '-m', ?)", message); err != nil {
+			return fmt.Errorf("failed to commit %s fleet: %v", team, err)
+		}
+	}
+
+	changed, err := uncommitted(d.db)
+	if err != nil {
+		return fmt.Errorf("failed to query status: %v", err)
+	}
+	if !changed {
+		return nil
+	}
+	if _, err := d.db.Exec("CALL DOLT_COMMIT('-a', '-m', ?)", message); err != nil {
+		return fmt.Errorf("failed to commit changes: %v", err)
+	}
+	return nil
+}
+
+// Uncommitted reports whether Commit has changes to record, on the shared branch or on a
+// private branch this connection has used
+func (d *Database) Uncommitted() (bool, error) {
+	branches := []querier{d.db}
+	for _, fleet := range d.fleets {
+		branches = append(branches, fleet)
+	}
+
+	for _, q := range branches {
+		changed, err := uncommitted(q)
+		if err != nil {
+			return false, fmt.Errorf("failed to query status: %v", err)
+		}
+		if changed {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// uncommitted reports whether the working set of a branch differs from its last commit
+func uncommitted(q querier) (bool, error) {
+	var changed int
+	if err := q.QueryRow("SELECT COUNT(*) FROM dolt_status").Scan(&changed); err != nil {
+		return false, err
+	}
+	return changed > 0, nil
+}
+
+// Version returns the hash of the current state of the database, which changes whenever the game does
+func (d *Database) Version() (string, error) {
+	var hash string
+	if err := d.db.QueryRow("SELECT dolt_hashof_db()").Scan(&hash); err != nil {
+		return "", fmt.Errorf("failed to get database hash: %v", err)
+	}
+	return hash, nil
+}
+
+// InsertCoin records that a team has joined the game
+func (d *Database) InsertCoin(team string) error {
+	if !game.ValidTeam(team) {
+		return fmt.Errorf("invalid team: %s", team)
+	}
+
+	query := `
+		INSERT INTO coin (team)
+		VALUES (?)
+	`
+	_, err := d.db.Exec(query, team)
+	if err != nil {
+		return fmt.Errorf("failed to insert coin: %v", err)
+	}
+	return nil
+}
+
+// TossCoin decides which team moves first once both teams have joined and records the
+// result in the game_state table. It returns the team that won the toss, or an empty
+// string if the toss has not happened because a team is still missing.
+func (d *Database) TossCoin() (string, error) {
+	g, err := d.LoadGame()
+	if err != nil {
+		return "", err
+	}
+
+	first := g.TossCoin()
+	if first == "" {
+		return "", nil
+	}
+	if err := saveState(d.db, g); err != nil {
+		return "", fmt.Errorf("failed to record coin toss: %v", err)
+	}
+	return first, nil
+}
+
+// GetTurn returns the team whose turn it is and the number of moves made so far.
+// The team is empty until the coin has been tossed.
+func (d *Database) GetTurn() (string, int, error) {
+	var team sql.NullString
+	var move int
+	err := d.db.QueryRow("SELECT current_team, move FROM game_state WHERE id = 1").Scan(&team, &move)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to query turn: %v", err)
+	}
+	return team.String, move, nil
+}
+
+// SunkShips returns the names of all ships on the given board that have no remaining undamaged segments
+func (d *Database) SunkShips(board string) ([]string, error) {
+	team, err := boardTeam(board)
+	if err != nil {
+		return nil, err
+	}
+
+	g, err := d.refereeGame(d.db)
+	if err != nil {
+		return nil, err
+	}
+	return g.SunkShips(team), nil
+}
+
+// PlaceRandomShips places all ships of the game's fleet randomly on the board for a team
+func (d *Database) PlaceRandomShips(team string) error {
+	fleet, err := d.fleetDB(team)
+	if err != nil {
+		return err
+	}
+	g, err := d.LoadGameFor(team)
+	if err != nil {
+		return err
+	}
+	board := g.Boards[team]
+
+	placed := len(board.Ships)
+	if err := g.PlaceRandomShips(team); err != nil {
+		return err
+	}
+	for _, ship := range board.Ships[placed:] {
+		if err := insertShip(fleet, team, ship); err != nil {
+			return fmt.Errorf("failed to insert ship: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// FireShot makes a complete move for a team in a single transaction. Acting as referee,
+// it resolves the shot by the game rules against the fleets on the teams' private
+// branches, then records only the result on the shared branch along with the new turn
+// and status, and commits the whole move to Dolt. A winning move is also tagged. Shots
+// the rules reject return the game package's errors, such as game.ErrAlreadyShot. Once the
+// game is won, both fleets are revealed in a further commit.
+func (d *Database) FireShot(team string, x, y int) (*game.Shot, error) {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	g, err := d.refereeGame(tx)
+	if err != nil {
+		return nil, err
+	}
+	shot, err := g.Fire(team, game.Coordinate{X: x, Y: y})
+	if err != nil {
+		return nil, err
+	}
+	if err := saveShot(tx, shot); err != nil {
+		return nil, err
+	}
+	if err := saveState(tx, g); err != nil {
+		return nil, err
+	}
+
+	// DOLT_COMMIT also commits the SQL transaction
+	_, err = tx.Exec("CALL DOLT_COMMIT('-a', '-m', ?)", ShotCommitMessage(shot))
+	if err != nil {
+		return nil, fmt.Errorf("failed to commit move: %v", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %v", err)
+	}
+
+	if shot.Winner != "" {
+		if err := d.tagWinner(shot.Winner); err != nil {
+			return nil, err
+		}
+		if err := d.reveal(); err != nil {
+			return nil, err
+		}
+	}
+
+	return &shot, nil
+}
+
+// Resign ends the game with a win for the opponent of team, commits and tags the result
+// and then reveals both fleets
+func (d *Database) Resign(team string) error {
+	g, err := d.LoadGame()
+	if err != nil {
+		return err
+	}
+	if err := g.Resign(team); err != nil {
+		return err
+	}
+	if err := saveState(d.db, g); err != nil {
+		return err
+	}
+	if err := d.Commit(resignMessage(g)); err != nil {
+		return err
+	}
+	if err := d.tagWinner(g.Winner); err != nil {
+		return err
+	}
+	return d.reveal()
+}
+
+// resignMessage returns the commit message recorded when a team resigns
+func resignMessage(g *game.Game) string {
+	return fmt.Sprintf("Team %s resigned; team %s has won the game", g.Resigned, g.Winner)
+}
+
+// RequestTakeback records and commits a team's request to take back the move it has just made
+func (d *Database) RequestTakeback(team string) error {
+	g, err := d.LoadGame()
+	if err != nil {
+		return err
+	}
+	if err := g.RequestTakeback(team); err != nil {
+		return err
+	}
+	if err := saveState(d.db, g); err != nil {
+		return err
+	}
+	return d.Commit(takebackMessage(g, team, "asked to take back"))
+}
+
+// AnswerTakeback accepts or declines the takeback the opponent of team has asked for.
+// Accepting reverts the commit that recorded the move, keeping any commit made since such
+// as the referee's verdicts, and notes the takeback in the same commit; declining commits
+// the cleared request.
+func (d *Database) AnswerTakeback(team string, accept bool) error {
+	g, err := d.LoadGame()
+	if err != nil {
+		return err
+	}
+	message := takebackMessage(g, team, "declined to take back")
+	if accept {
+		message = takebackMessage(g, team, "agreed to take back")
+	}
+	if err := g.AnswerTakeback(team); err != nil {
+		return err
+	}
+
+	if !accept {
+		if err := saveState(d.db, g); err != nil {
+			return err
+		}
+		return d.Commit(message)
+	}
+
+	moves, err := d.Moves()
+	if err != nil {
+		return err
+	}
+	if len(moves) == 0 || moves[len(moves)-1].Move != g.Move {
+		return fmt.Errorf("failed to find the commit of move %d", g.Move)
+	}
+	move := moves[len(moves)-1].Hash
+
+	if _, err := d.db.Exec("CALL DOLT_REVERT(?)", move); err != nil {
+		return fmt.Errorf("failed to revert move %d: %v", g.Move, err)
+	}
+	// The revert leaves the request in place; clear it and note the takeback
+	reverted, err := d.LoadGame()
+	if err != nil {
+		return err
+	}
+	reverted.Takeback = ""
+	if err := saveState(d.db, reverted); err != nil {
+		return err
+	}
+	if _, err := d.db.Exec("CALL DOLT_COMMIT('--amend', '-a', '-m', ?)", message); err != nil {
+		return fmt.Errorf("failed to commit takeback: %v", err)
+	}
+	return nil
+}
+
+// takebackMessage returns the commit message recorded when team asks for or answers a
+// takeback of the last move
+func takebackMessage(g *game.Game, team, action string) string {
+	message := fmt.Sprintf("Team %s %s move %d", team, action, g.Move)
+	if g.Takeback != "" && g.Takeback != team {
+		message += fmt.Sprintf(" by team %s", g.Takeback)
+	}
+	return message
+}
+
+// verdictMessage returns the commit message recorded with the referee's verdict on a commit
+func verdictMessage(v Verdict) string {
+	if v.Legal() {
+		return fmt.Sprintf("%scommit %s is legal", refereePrefix, v.Hash)
+	}
+	message := fmt.Sprintf("%scommit %s broke the rules: %s", refereePrefix, v.Hash, strings.Join(v.Problems, "; "))
+	if v.Reverted {
+		message += "; it was reverted"
+	}
+	return message
+}
+
+// revertMessage returns the commit message recorded when the referee reverts a commit
+func revertMessage(hash string) string {
+	return fmt.Sprintf("%srevert commit %s", refereePrefix, hash)
+}
+
+// ShotCommitMessage returns the Dolt commit message recorded for a move
+func ShotCommitMessage(shot game.Shot) string {
+	message := fmt.Sprintf("Team %s shot at (%s) and it was a %s", shot.Team, shot.Target, shot.Outcome())
+	if shot.Winner != "" {
+		message += fmt.Sprintf("; team %s has sunk the entire enemy fleet and won the game", shot.Winner)
+	}
+	return message
+}
+
+// saveShot records a shot on the shooting team's shots board. The ship column of the shot
+// names the ship it sunk; nothing is revealed about ships that have not been sunk.
+func saveShot(q querier, shot game.Shot) error {
+	state := "M"
+	if shot.Hit {
+		state = "H"
+	}
+
+	query := `
+		INSERT INTO board_states (x, y, board, state, ship)
+		VALUES (?, ?, ?, ?, ?)
+	`
+	_, err := q.Exec(query, shot.Target.X, shot.Target.Y, fmt.Sprintf("%s_shots", shot.Team), state, nullString(shot.Sunk))
+	if err != nil {
+		return fmt.Errorf("failed to record shot: %v", err)
+	}
+	return nil
+}
+
+// winnerTag returns the name of the tag recording that team won a game
+func winnerTag(gameID, team string) string {
+	return fmt.Sprintf("game_%s_%s_won", gameID, team)
+}
+
+// tagWinner tags the current commit with the result of the game
+func (d *Database) tagWinner(winner string) error {
+	_, err := d.db.Exec("CALL DOLT_TAG(?, 'HEAD')", winnerTag(d.gameID, winner))
+	if err != nil {
+		return fmt.Errorf("failed to tag game result: %v", err)
+	}
 	return nil
 }
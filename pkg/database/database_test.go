@@ -1,18 +1,26 @@
 package database
 
 import (
+	"errors"
+	"strings"
 	"testing"
+
+	"battleship/pkg/game"
 )
 
 func setupTestDB(t *testing.T) (*Database, func()) {
-	// Create a new database instance
-	db, err := New("testId")
+	return setupTestGame(t, game.DefaultSettings())
+}
+
+func setupTestGame(t *testing.T, settings game.Settings) (*Database, func()) {
+	// Create a new game, replacing the one left by the previous test
+	db, err := Create(DefaultConfig(), "testId", true)
 	if err != nil {
 		t.Fatalf("Failed to create test database: %v", err)
 	}
 
 	// Initialize the database
-	if err := db.Initialize(); err != nil {
+	if err := db.Initialize(settings); err != nil {
 		t.Fatalf("Failed to initialize test database: %v", err)
 	}
 
@@ -24,18 +32,41 @@ func setupTestDB(t *testing.T) (*Database, func()) {
 	return db, cleanup
 }
 
+// oneShipSettings returns the settings of a square test game whose fleet is a single destroyer
+func oneShipSettings(size int) game.Settings {
+	return game.Settings{Fleet: game.Fleet{{Name: "Destroyer", Length: 2}}, Width: size, Height: size}
+}
+
+// setTurn puts the game in progress with the given team to play
+func setTurn(t *testing.T, db *Database, team string) {
+	t.Helper()
+	_, err := db.Exec("UPDATE game_state SET first_team = ?, current_team = ? WHERE id = 1", team, team)
+	if err != nil {
+		t.Fatalf("Failed to set turn: %v", err)
+	}
+}
+
 func TestShipInsertionAndRetrieval(t *testing.T) {
 	db, cleanup := setupTestDB(t)
 	defer cleanup()
 
 	// Insert a carrier (5 units) horizontally at (0,0)
-	err := db.InsertShip("red_ships", 0, 0, 5, Horizontal)
+	err := db.InsertShip("red_ships", "Carrier", 0, 0, 5, game.Horizontal)
 	if err != nil {
 		t.Fatalf("Failed to insert ship: %v", err)
 	}
 
-	// Query the database to verify the ship was inserted correctly
-	rows, err := db.Query("SELECT x, y, state FROM board_states WHERE board = 'red_ships' ORDER BY x, y")
+	// The shared branch must not reveal the ship
+	var shared int
+	if err := db.QueryRow("SELECT COUNT(*) FROM board_states WHERE board = 'red_ships'").Scan(&shared); err != nil {
+		t.Fatalf("Failed to query shared board state: %v", err)
+	}
+	if shared != 0 {
+		t.Errorf("Shared branch has %d red ship segments, want none", shared)
+	}
+
+	// Query red's private branch to verify the ship was inserted correctly
+	rows, err := db.Query("SELECT x, y, state FROM `battleship/private_testId_red`.board_states WHERE board = 'red_ships' ORDER BY x, y")
 	if err != nil {
 		t.Fatalf("Failed to query board state: %v", err)
 	}
@@ -78,61 +109,73 @@ func TestShipBoundsValidation(t *testing.T) {
 
 	tests := []struct {
 		name      string
+		ship      string
 		x, y      int
 		length    int
-		direction Direction
+		direction game.Direction
 		wantErr   bool
 	}{
 		{
 			name: "Ship fits horizontally",
+			ship: "Carrier",
 			x:    0, y: 0,
 			length:    5,
-			direction: Horizontal,
+			direction: game.Horizontal,
 			wantErr:   false,
 		},
 		{
 			name: "Ship fits vertically",
+			ship: "Carrier",
 			x:    0, y: 0,
 			length:    5,
-			direction: Vertical,
+			direction: game.Vertical,
 			wantErr:   false,
 		},
 		{
 			name: "Ship too long horizontally",
+			ship: "Carrier",
 			x:    7, y: 0,
 			length:    5,
-			direction: Horizontal,
+			direction: game.Horizontal,
 			wantErr:   true,
 		},
 		{
 			name: "Ship too long vertically",
+			ship: "Carrier",
 			x:    0, y: 7,
 			length:    5,
-			direction: Vertical,
+			direction: game.Vertical,
 			wantErr:   true,
 		},
 		{
 			name: "Ship out of bounds x",
+			ship: "Cruiser",
 			x:    -1, y: 0,
 			length:    3,
-			direction: Horizontal,
+			direction: game.Horizontal,
 			wantErr:   true,
 		},
 		{
 			name: "Ship out of bounds y",
+			ship: "Cruiser",
 			x:    0, y: -1,
 			length:    3,
-			direction: Vertical,
+			direction: game.Vertical,
 			wantErr:   true,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			err := db.InsertShip("red_ships", tt.x, tt.y, tt.length, tt.direction)
+			err := db.InsertShip("red_ships", tt.ship, tt.x, tt.y, tt.length, tt.direction)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("InsertShip() error = %v, wantErr %v", err, tt.wantErr)
 			}
+
+			// Clear the private board so ships from earlier cases do not overlap
+			if err := db.ClearShips("red_ships"); err != nil {
+				t.Fatalf("Failed to clear ships: %v", err)
+			}
 		})
 	}
 }
@@ -144,48 +187,510 @@ func TestInvalidShipLength(t *testing.T) {
 
 	tests := []struct {
 		name      string
+		ship      string
 		length    int
-		direction Direction
+		direction game.Direction
 		wantErr   bool
 	}{
 		{
 			name:      "Valid length (2)",
+			ship:      "Destroyer",
 			length:    2,
-			direction: Horizontal,
+			direction: game.Horizontal,
 			wantErr:   false,
 		},
 		{
 			name:      "Valid length (5)",
+			ship:      "Carrier",
 			length:    5,
-			direction: Horizontal,
+			direction: game.Horizontal,
 			wantErr:   false,
 		},
 		{
 			name:      "Invalid length (1)",
+			ship:      "Destroyer",
 			length:    1,
-			direction: Horizontal,
+			direction: game.Horizontal,
 			wantErr:   true,
 		},
 		{
 			name:      "Invalid length (6)",
+			ship:      "Carrier",
 			length:    6,
-			direction: Horizontal,
+			direction: game.Horizontal,
 			wantErr:   true,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			err := db.InsertShip("red_ships", 0, 0, tt.length, tt.direction)
+			err := db.InsertShip("red_ships", tt.ship, 0, 0, tt.length, tt.direction)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("InsertShip() error = %v, wantErr %v", err, tt.wantErr)
 			}
 
-			// Reset the Dolt database to its initial state after each test case
-			_, err = db.Exec("CALL DOLT_RESET('--hard')")
-			if err != nil {
-				t.Fatalf("Failed to reset Dolt database: %v", err)
+			// Clear the private board so ships from earlier cases do not overlap
+			if err := db.ClearShips("red_ships"); err != nil {
+				t.Fatalf("Failed to clear ships: %v", err)
 			}
 		})
 	}
 }
+
+func TestSunkShips(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	// Insert a destroyer (2 units) horizontally at (0,0)
+	if err := db.InsertShip("blue_ships", "Destroyer", 0, 0, 2, game.Horizontal); err != nil {
+		t.Fatalf("Failed to insert ship: %v", err)
+	}
+	setTurn(t, db, "red")
+
+	shots := []struct {
+		x, y int
+		want string
+	}{
+		{5, 5, "miss"},
+		{0, 0, "hit"},
+		{1, 0, "hit and sunk Destroyer"},
+	}
+
+	for _, shot := range shots {
+		setTurn(t, db, "red")
+		result, err := db.FireShot("red", shot.x, shot.y)
+		if err != nil {
+			t.Fatalf("FireShot(%d, %d) error = %v", shot.x, shot.y, err)
+		}
+		if result.Outcome() != shot.want {
+			t.Errorf("FireShot(%d, %d) = %q, want %q", shot.x, shot.y, result.Outcome(), shot.want)
+		}
+	}
+
+	sunk, err := db.SunkShips("blue_ships")
+	if err != nil {
+		t.Fatalf("SunkShips() error = %v", err)
+	}
+	if len(sunk) != 1 || sunk[0] != "Destroyer" {
+		t.Errorf("SunkShips() = %v, want [Destroyer]", sunk)
+	}
+}
+
+func TestCoinTossAndTurns(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	if err := db.InsertCoin("red"); err != nil {
+		t.Fatalf("InsertCoin(red) error = %v", err)
+	}
+	first, err := db.TossCoin()
+	if err != nil {
+		t.Fatalf("TossCoin() error = %v", err)
+	}
+	if first != "" {
+		t.Fatalf("TossCoin() = %q with only one team joined, want empty", first)
+	}
+
+	if err := db.InsertCoin("blue"); err != nil {
+		t.Fatalf("InsertCoin(blue) error = %v", err)
+	}
+	first, err = db.TossCoin()
+	if err != nil {
+		t.Fatalf("TossCoin() error = %v", err)
+	}
+	if first != "red" && first != "blue" {
+		t.Fatalf("TossCoin() = %q, want red or blue", first)
+	}
+
+	turn, move, err := db.GetTurn()
+	if err != nil {
+		t.Fatalf("GetTurn() error = %v", err)
+	}
+	if turn != first || move != 0 {
+		t.Errorf("GetTurn() = (%q, %d), want (%q, 0)", turn, move, first)
+	}
+
+	// Any shot, even a miss on an empty board, passes the turn
+	if _, err := db.FireShot(first, 0, 0); err != nil {
+		t.Fatalf("FireShot() error = %v", err)
+	}
+	turn, move, err = db.GetTurn()
+	if err != nil {
+		t.Fatalf("GetTurn() error = %v", err)
+	}
+	if turn == first || move != 1 {
+		t.Errorf("GetTurn() after a shot = (%q, %d), want opponent of %q and move 1", turn, move, first)
+	}
+}
+
+func TestFireShotErrors(t *testing.T) {
+	db, cleanup := setupTestGame(t, oneShipSettings(10))
+	defer cleanup()
+
+	if err := db.InsertShip("blue_ships", "Destroyer", 0, 0, 2, game.Horizontal); err != nil {
+		t.Fatalf("Failed to insert ship: %v", err)
+	}
+	setTurn(t, db, "red")
+
+	if _, err := db.FireShot("blue", 3, 3); !errors.Is(err, game.ErrNotYourTurn) {
+		t.Errorf("FireShot() out of turn error = %v, want %v", err, game.ErrNotYourTurn)
+	}
+
+	if _, err := db.FireShot("red", 3, 3); err != nil {
+		t.Fatalf("FireShot() error = %v", err)
+	}
+	setTurn(t, db, "red")
+	if _, err := db.FireShot("red", 3, 3); !errors.Is(err, game.ErrAlreadyShot) {
+		t.Errorf("FireShot() on the same cell error = %v, want %v", err, game.ErrAlreadyShot)
+	}
+
+	for _, x := range []int{0, 1} {
+		setTurn(t, db, "red")
+		if _, err := db.FireShot("red", x, 0); err != nil {
+			t.Fatalf("FireShot() error = %v", err)
+		}
+	}
+	setTurn(t, db, "red")
+	if _, err := db.FireShot("red", 4, 4); !errors.Is(err, game.ErrGameOver) {
+		t.Errorf("FireShot() after the game ended error = %v, want %v", err, game.ErrGameOver)
+	}
+}
+
+func TestFireShot(t *testing.T) {
+	db, cleanup := setupTestGame(t, oneShipSettings(10))
+	defer cleanup()
+
+	if err := db.InsertShip("blue_ships", "Destroyer", 0, 0, 2, game.Horizontal); err != nil {
+		t.Fatalf("Failed to insert ship: %v", err)
+	}
+	if err := db.InsertShip("red_ships", "Destroyer", 0, 0, 2, game.Horizontal); err != nil {
+		t.Fatalf("Failed to insert ship: %v", err)
+	}
+	setTurn(t, db, "red")
+
+	moves := []struct {
+		team   string
+		x, y   int
+		want   string
+		winner string
+	}{
+		{"red", 0, 0, "hit", ""},
+		{"blue", 9, 9, "miss", ""},
+		{"red", 1, 0, "hit and sunk Destroyer", "red"},
+	}
+
+	for i, move := range moves {
+		result, err := db.FireShot(move.team, move.x, move.y)
+		if err != nil {
+			t.Fatalf("FireShot(%s, %d, %d) error = %v", move.team, move.x, move.y, err)
+		}
+		if result.Outcome() != move.want {
+			t.Errorf("FireShot(%s, %d, %d) outcome = %q, want %q", move.team, move.x, move.y, result.Outcome(), move.want)
+		}
+		if result.Winner != move.winner {
+			t.Errorf("FireShot(%s, %d, %d) winner = %q, want %q", move.team, move.x, move.y, result.Winner, move.winner)
+		}
+		if result.Move != i+1 {
+			t.Errorf("FireShot(%s, %d, %d) move = %d, want %d", move.team, move.x, move.y, result.Move, i+1)
+		}
+	}
+
+	// The winning move ends the game and reveals both fleets
+	g, err := db.LoadGame()
+	if err != nil {
+		t.Fatalf("LoadGame() error = %v", err)
+	}
+	if g.Status() != game.StatusRedWon || !g.Revealed {
+		t.Errorf("after the winning move status = %q, revealed = %v, want %q and revealed", g.Status(), g.Revealed, game.StatusRedWon)
+	}
+	if !g.FleetDestroyed("blue") || g.FleetDestroyed("red") {
+		t.Error("FleetDestroyed() after the winning move, want only the blue fleet destroyed")
+	}
+}
+
+func TestShipOverlap(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	if err := db.InsertShip("red_ships", "Carrier", 0, 0, 5, game.Horizontal); err != nil {
+		t.Fatalf("Failed to insert ship: %v", err)
+	}
+
+	err := db.InsertShip("red_ships", "Battleship", 2, 0, 4, game.Vertical)
+	if !errors.Is(err, game.ErrInvalidPlacement) {
+		t.Errorf("InsertShip() over another ship error = %v, want %v", err, game.ErrInvalidPlacement)
+	}
+
+	// The same cells are free on the other team's board
+	if err := db.InsertShip("blue_ships", "Battleship", 2, 0, 4, game.Vertical); err != nil {
+		t.Errorf("InsertShip() on the other board error = %v", err)
+	}
+}
+
+func TestTakeback(t *testing.T) {
+	db, cleanup := setupTestGame(t, oneShipSettings(10))
+	defer cleanup()
+
+	if err := db.InsertShip("blue_ships", "Destroyer", 0, 0, 2, game.Horizontal); err != nil {
+		t.Fatalf("Failed to insert ship: %v", err)
+	}
+	setTurn(t, db, "red")
+	if err := db.Commit("Set up takeback test"); err != nil {
+		t.Fatalf("Commit() error = %v", err)
+	}
+
+	if _, err := db.FireShot("red", 0, 0); err != nil {
+		t.Fatalf("FireShot() error = %v", err)
+	}
+	if err := db.RequestTakeback("red"); err != nil {
+		t.Fatalf("RequestTakeback() error = %v", err)
+	}
+	// A verdict recorded before the answer survives the takeback
+	history, err := db.History()
+	if err != nil {
+		t.Fatalf("History() error = %v", err)
+	}
+	if err := db.RecordVerdict(Verdict{Hash: history[len(history)-2].Hash, Message: history[len(history)-2].Message}); err != nil {
+		t.Fatalf("RecordVerdict() error = %v", err)
+	}
+	if err := db.AnswerTakeback("blue", true); err != nil {
+		t.Fatalf("AnswerTakeback() error = %v", err)
+	}
+
+	g, err := db.LoadGame()
+	if err != nil {
+		t.Fatalf("LoadGame() error = %v", err)
+	}
+	if g.Move != 0 || g.Turn != "red" || len(g.Shots["red"]) != 0 || g.Takeback != "" {
+		t.Errorf("after the takeback move, turn = %d, %q with %d shots, want 0, red and none", g.Move, g.Turn, len(g.Shots["red"]))
+	}
+
+	history, err = db.History()
+	if err != nil {
+		t.Fatalf("History() error = %v", err)
+	}
+	if last := history[len(history)-1].Message; last != "Team blue agreed to take back move 1 by team red" {
+		t.Errorf("last commit = %q, want the takeback noted", last)
+	}
+	if verdicts, err := db.Verdicts(); err != nil || len(verdicts) != 1 {
+		t.Errorf("Verdicts() after the takeback = %v, %v, want the recorded verdict", verdicts, err)
+	}
+	if moves, err := db.Moves(); err != nil || len(moves) != 0 {
+		t.Errorf("Moves() after the takeback = %v, %v, want none", moves, err)
+	}
+}
+
+func TestCommitLayout(t *testing.T) {
+	db, cleanup := setupTestGame(t, oneShipSettings(10))
+	defer cleanup()
+
+	if err := db.InsertCoin("red"); err != nil {
+		t.Fatalf("InsertCoin() error = %v", err)
+	}
+	if err := db.CommitLayout("red"); err == nil {
+		t.Error("CommitLayout() before the fleet was placed succeeded, want an error")
+	}
+	if err := db.InsertShip("red_ships", "Destroyer", 0, 0, 2, game.Horizontal); err != nil {
+		t.Fatalf("Failed to insert ship: %v", err)
+	}
+	if err := db.CommitLayout("red"); err != nil {
+		t.Fatalf("CommitLayout() error = %v", err)
+	}
+
+	// The shared branch has the hash but not the salt
+	shared, err := db.LoadGame()
+	if err != nil {
+		t.Fatalf("LoadGame() error = %v", err)
+	}
+	if c := shared.Commitments["red"]; len(c.Hash) != 64 || c.Salt != "" {
+		t.Errorf("shared commitment = %+v, want a hash without the salt", c)
+	}
+
+	red, err := db.LoadGameFor("red")
+	if err != nil {
+		t.Fatalf("LoadGameFor() error = %v", err)
+	}
+	if !red.Commitments["red"].Matches(red.Boards["red"].Ships) {
+		t.Errorf("red commitment %+v does not match its fleet", red.Commitments["red"])
+	}
+}
+
+func TestCommitPrivateOnly(t *testing.T) {
+	db, cleanup := setupTestGame(t, oneShipSettings(10))
+	defer cleanup()
+
+	before, err := db.History()
+	if err != nil {
+		t.Fatalf("History() error = %v", err)
+	}
+
+	// Placing a fleet changes only the team's private branch
+	if err := db.InsertShip("red_ships", "Destroyer", 0, 0, 2, game.Horizontal); err != nil {
+		t.Fatalf("Failed to insert ship: %v", err)
+	}
+	if err := db.Commit("Place the red fleet"); err != nil {
+		t.Fatalf("Commit() of a private change error = %v", err)
+	}
+	if uncommitted, err := db.Uncommitted(); err != nil || uncommitted {
+		t.Errorf("Uncommitted() after the commit = %v, %v, want false", uncommitted, err)
+	}
+	if after, _ := db.History(); len(after) != len(before) {
+		t.Errorf("shared history has %d commits after a private commit, want %d", len(after), len(before))
+	}
+}
+
+func TestRevertRestoresFleet(t *testing.T) {
+	db, cleanup := setupTestGame(t, oneShipSettings(10))
+	defer cleanup()
+
+	if err := db.InsertCoin("red"); err != nil {
+		t.Fatalf("InsertCoin() error = %v", err)
+	}
+	if err := db.InsertShip("red_ships", "Destroyer", 0, 0, 2, game.Horizontal); err != nil {
+		t.Fatalf("Failed to insert ship: %v", err)
+	}
+	if err := db.CommitLayout("red"); err != nil {
+		t.Fatalf("CommitLayout() error = %v", err)
+	}
+	setTurn(t, db, "blue")
+	if err := db.Commit("Set up revert test"); err != nil {
+		t.Fatalf("Commit() error = %v", err)
+	}
+
+	// Red moves its destroyer out of the way of blue's shot
+	if err := db.ClearShips("red_ships"); err != nil {
+		t.Fatalf("ClearShips() error = %v", err)
+	}
+	if err := db.InsertShip("red_ships", "Destroyer", 5, 5, 2, game.Horizontal); err != nil {
+		t.Fatalf("Failed to insert ship: %v", err)
+	}
+	if err := db.Commit("Move the destroyer"); err != nil {
+		t.Fatalf("Commit() error = %v", err)
+	}
+	if _, err := db.FireShot("blue", 0, 0); err != nil {
+		t.Fatalf("FireShot() error = %v", err)
+	}
+
+	history, err := db.History()
+	if err != nil {
+		t.Fatalf("History() error = %v", err)
+	}
+	if err := db.Revert(history[len(history)-1].Hash); err != nil {
+		t.Fatalf("Revert() error = %v", err)
+	}
+
+	red, err := db.LoadGameFor("red")
+	if err != nil {
+		t.Fatalf("LoadGameFor() error = %v", err)
+	}
+	if !red.Commitments["red"].Matches(red.Boards["red"].Ships) {
+		t.Errorf("red fleet after the revert = %+v, want the committed layout", red.Boards["red"].Ships)
+	}
+	if len(red.Shots["blue"]) != 0 || red.Turn != "blue" {
+		t.Errorf("after the revert turn = %q with %d blue shots, want blue to fire again", red.Turn, len(red.Shots["blue"]))
+	}
+}
+
+func TestRecordVerdict(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	if verdicts, err := db.Verdicts(); err != nil || len(verdicts) != 0 {
+		t.Fatalf("Verdicts() before refereeing = %v, %v, want none", verdicts, err)
+	}
+
+	history, err := db.History()
+	if err != nil {
+		t.Fatalf("History() error = %v", err)
+	}
+	v := Verdict{Hash: history[0].Hash, Message: history[0].Message, Problems: []string{"first problem", "second problem"}}
+	if err := db.RecordVerdict(v); err != nil {
+		t.Fatalf("RecordVerdict() error = %v", err)
+	}
+
+	verdicts, err := db.Verdicts()
+	if err != nil {
+		t.Fatalf("Verdicts() error = %v", err)
+	}
+	if len(verdicts) != 1 || verdicts[0].Hash != v.Hash || len(verdicts[0].Problems) != 2 || verdicts[0].Legal() {
+		t.Errorf("Verdicts() = %+v, want the recorded verdict", verdicts)
+	}
+
+	history, _ = db.History()
+	if last := history[len(history)-1]; !last.ByReferee() {
+		t.Errorf("last commit = %q, want the referee's verdict", last.Message)
+	}
+}
+
+func TestSchemaConstraints(t *testing.T) {
+	db, cleanup := setupTestGame(t, oneShipSettings(5))
+	defer cleanup()
+
+	if _, err := db.Exec("INSERT INTO board_states (x, y, board, state) VALUES (0, 0, 'red_shots', 'M')"); err != nil {
+		t.Fatalf("Failed to insert shot: %v", err)
+	}
+
+	// Rows rejected by a trigger must fail with the trigger's message
+	tests := []struct {
+		name    string
+		query   string
+		message string
+	}{
+		{"cell off the board", "INSERT INTO board_states (x, y, board, state) VALUES (5, 0, 'red_shots', 'M')", ""},
+		{"negative coordinate", "INSERT INTO board_states (x, y, board, state) VALUES (0, -1, 'blue_shots', 'M')", ""},
+		{"ship segment on a shot board", "INSERT INTO board_states (x, y, board, state) VALUES (1, 1, 'red_shots', 'S')", ""},
+		{"miss on a ship board", "INSERT INTO board_states (x, y, board, state) VALUES (1, 1, 'red_ships', 'M')", ""},
+		{"ship off the board", "INSERT INTO ships (board, name, x, y, length, vertical) VALUES ('red_ships', 'Destroyer', 4, 0, 2, FALSE)", ""},
+		{"vertical ship off the board", "INSERT INTO ships (board, name, x, y, length, vertical) VALUES ('red_ships', 'Destroyer', 0, 4, 2, TRUE)", ""},
+		{"second shot at a cell", "INSERT INTO board_states (x, y, board, state) VALUES (0, 0, 'red_shots', 'H')", "that cell has already been shot"},
+		{"changed shot", "UPDATE board_states SET state = 'H' WHERE board = 'red_shots'", "recorded shots cannot be changed"},
+		{"removed shot", "DELETE FROM board_states WHERE board = 'red_shots'", "recorded shots cannot be removed"},
+	}
+
+	for _, tt := range tests {
+		_, err := db.Exec(tt.query)
+		if err == nil {
+			t.Errorf("%s: Exec(%q) succeeded, want it rejected", tt.name, tt.query)
+			continue
+		}
+		if !strings.Contains(err.Error(), tt.message) {
+			t.Errorf("%s: Exec(%q) error = %v, want %q", tt.name, tt.query, err, tt.message)
+		}
+	}
+
+	// Legal rows are still accepted
+	if _, err := db.Exec("INSERT INTO ships (board, name, x, y, length, vertical) VALUES ('red_ships', 'Destroyer', 0, 3, 2, TRUE)"); err != nil {
+		t.Errorf("Failed to insert a ship on the board: %v", err)
+	}
+	if _, err := db.Exec("INSERT INTO board_states (x, y, board, state) VALUES (4, 4, 'blue_shots', 'H')"); err != nil {
+		t.Errorf("Failed to insert a shot on the board: %v", err)
+	}
+
+	// No shot can be added once the game is over
+	if _, err := db.Exec("UPDATE game_state SET status = ? WHERE id = 1", game.StatusRedWon); err != nil {
+		t.Fatalf("Failed to end game: %v", err)
+	}
+	_, err := db.Exec("INSERT INTO board_states (x, y, board, state) VALUES (3, 3, 'blue_shots', 'M')")
+	if err == nil || !strings.Contains(err.Error(), "the game is over") {
+		t.Errorf("Exec() of a shot after the game ended error = %v, want %q", err, "the game is over")
+	}
+}
+
+func TestCreateReplacesFinishedGame(t *testing.T) {
+	settings := oneShipSettings(5)
+
+	// Red wins the same game twice; the second win must not collide with the first's tag
+	for i := 0; i < 2; i++ {
+		db, cleanup := setupTestGame(t, settings)
+		if err := db.InsertShip("blue_ships", "Destroyer", 0, 0, 2, game.Horizontal); err != nil {
+			t.Fatalf("Failed to insert ship: %v", err)
+		}
+		for _, x := range []int{0, 1} {
+			setTurn(t, db, "red")
+			if _, err := db.FireShot("red", x, 0); err != nil {
+				t.Fatalf("game %d: FireShot() error = %v", i+1, err)
+			}
+		}
+		cleanup()
+	}
+}
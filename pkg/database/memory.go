@@ -0,0 +1,450 @@
+package database
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"sync"
+	"time"
+
+	"battleship/pkg/game"
+)
+
+// memoryCommit is a snapshot of the game taken by Memory.Commit
+type memoryCommit struct {
+	message string
+	date    time.Time
+	game    *game.Game
+}
+
+// Memory is a Store that keeps a single game in memory. Like Dolt it keeps a snapshot of
+// every commit, but nothing is shared between processes or survives the program exiting.
+type Memory struct {
+	mu       sync.Mutex
+	game     *game.Game // working state; nil until the game is initialized
+	commits  []memoryCommit
+	verdicts []Verdict
+	version  int  // incremented on every change
+	dirty    bool // the working state has changed since the last commit
+}
+
+// NewMemory creates an empty in-memory store
+func NewMemory() *Memory {
+	return &Memory{}
+}
+
+// Initialize creates a new game with the given settings and commits it
+func (m *Memory) Initialize(settings game.Settings) error {
+	if err := settings.Validate(); err != nil {
+		return fmt.Errorf("invalid game settings: %v", err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.game != nil {
+		return fmt.Errorf("the game has already been started")
+	}
+
+	m.game = game.New(settings)
+	m.commit(initializeMessage)
+	return nil
+}
+
+// Created returns the time the game was started
+func (m *Memory) Created() (time.Time, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(m.commits) == 0 {
+		return time.Time{}, ErrNotStarted
+	}
+	return m.commits[0].date, nil
+}
+
+// GetSettings returns the board dimensions and fleet chosen when the game was started
+func (m *Memory) GetSettings() (game.Settings, error) {
+	g, err := m.LoadGame()
+	if err != nil {
+		return game.Settings{}, err
+	}
+	return g.Settings, nil
+}
+
+// LoadGame returns a copy of the shared state of the game, without either fleet
+func (m *Memory) LoadGame() (*game.Game, error) {
+	return m.LoadGameFor("")
+}
+
+// LoadGameFor returns a copy of the game as a team sees it, with only its own fleet
+func (m *Memory) LoadGameFor(team string) (*game.Game, error) {
+	if team != "" && !game.ValidTeam(team) {
+		return nil, fmt.Errorf("invalid team: %s", team)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.game == nil {
+		return nil, ErrNotStarted
+	}
+	return m.game.View(team), nil
+}
+
+// InsertCoin records that a team has joined the game
+func (m *Memory) InsertCoin(team string) error {
+	return m.update(func(g *game.Game) error {
+		return g.Join(team)
+	})
+}
+
+// TossCoin decides which team moves first once both teams have joined
+func (m *Memory) TossCoin() (string, error) {
+	var first string
+	err := m.update(func(g *game.Game) error {
+		first = g.TossCoin()
+		return nil
+	})
+	return first, err
+}
+
+// InsertShip places a named ship on a board such as red_ships
+func (m *Memory) InsertShip(board, name string, x, y int, length int, direction game.Direction) error {
+	team, err := boardTeam(board)
+	if err != nil {
+		return err
+	}
+
+	ship := game.Ship{Name: name, Length: length, Bow: game.Coordinate{X: x, Y: y}, Direction: direction}
+	return m.update(func(g *game.Game) error {
+		return g.PlaceShip(team, ship)
+	})
+}
+
+// PlaceRandomShips places every unplaced ship of the fleet randomly for a team
+func (m *Memory) PlaceRandomShips(team string) error {
+	return m.update(func(g *game.Game) error {
+		return g.PlaceRandomShips(team)
+	})
+}
+
+// ClearShips removes every ship from a board such as red_ships
+func (m *Memory) ClearShips(board string) error {
+	team, err := boardTeam(board)
+	if err != nil {
+		return err
+	}
+
+	return m.update(func(g *game.Game) error {
+		g.Boards[team].Ships = nil
+		return nil
+	})
+}
+
+// CommitLayout records a salted hash of a team's fleet layout
+func (m *Memory) CommitLayout(team string) error {
+	return m.update(func(g *game.Game) error {
+		return g.CommitLayout(team)
+	})
+}
+
+// GetTurn returns the team to play and the number of moves made so far
+func (m *Memory) GetTurn() (string, int, error) {
+	g, err := m.LoadGame()
+	if err != nil {
+		return "", 0, err
+	}
+	return g.Turn, g.Move, nil
+}
+
+// FireShot makes a move for a team and commits it, revealing both fleets if it wins the game
+func (m *Memory) FireShot(team string, x, y int) (*game.Shot, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.game == nil {
+		return nil, ErrNotStarted
+	}
+
+	shot, err := m.game.Fire(team, game.Coordinate{X: x, Y: y})
+	if err != nil {
+		return nil, err
+	}
+	m.commit(ShotCommitMessage(shot))
+	if shot.Winner != "" {
+		if err := m.reveal(); err != nil {
+			return nil, err
+		}
+	}
+	return &shot, nil
+}
+
+// Resign ends the game with a win for the opponent of team, commits the result and reveals both fleets
+func (m *Memory) Resign(team string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	err := m.change(func(g *game.Game) error {
+		return g.Resign(team)
+	})
+	if err != nil {
+		return err
+	}
+	m.commit(resignMessage(m.game))
+	return m.reveal()
+}
+
+// reveal makes both fleets and their salts public once the game is over and commits them.
+// The caller must hold m.mu.
+func (m *Memory) reveal() error {
+	err := m.change(func(g *game.Game) error {
+		return g.Reveal()
+	})
+	if err != nil {
+		return err
+	}
+	m.commit(revealMessage)
+	return nil
+}
+
+// RequestTakeback asks to take back the move a team has just made
+func (m *Memory) RequestTakeback(team string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	err := m.change(func(g *game.Game) error {
+		return g.RequestTakeback(team)
+	})
+	if err != nil {
+		return err
+	}
+	m.commit(takebackMessage(m.game, team, "asked to take back"))
+	return nil
+}
+
+// AnswerTakeback accepts or declines the takeback the opponent of team has asked for.
+// Accepting restores the shared state from before the move, keeping the commits made
+// since, and commits a note of the takeback.
+func (m *Memory) AnswerTakeback(team string, accept bool) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.game == nil {
+		return ErrNotStarted
+	}
+
+	message := takebackMessage(m.game, team, "declined to take back")
+	if accept {
+		message = takebackMessage(m.game, team, "agreed to take back")
+	}
+	move := m.game.Move
+	err := m.change(func(g *game.Game) error {
+		return g.AnswerTakeback(team)
+	})
+	if err != nil {
+		return err
+	}
+
+	if accept {
+		// The last commit with fewer moves is the one from before the move; like a Dolt
+		// revert of the shared branch, it leaves the fleets alone
+		i := len(m.commits) - 1
+		for i > 0 && m.commits[i].game.Move >= move {
+			i--
+		}
+		restored := m.commits[i].game.Clone()
+		restored.Boards = m.game.Boards
+		m.game = restored
+	}
+	m.commit(message)
+	return nil
+}
+
+// Commit takes a snapshot of the game with the given message
+func (m *Memory) Commit(message string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.game == nil {
+		return ErrNotStarted
+	}
+
+	// As on Dolt, a change to nothing but the private fleets is not part of the shared history
+	if reflect.DeepEqual(m.game.View(""), m.commits[len(m.commits)-1].game.View("")) {
+		m.dirty = false
+		return nil
+	}
+	m.commit(message)
+	return nil
+}
+
+// Uncommitted reports whether the game has changed since the last commit
+func (m *Memory) Uncommitted() (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.game == nil {
+		return false, ErrNotStarted
+	}
+	return m.dirty, nil
+}
+
+// Version returns a value that changes whenever the game does
+func (m *Memory) Version() (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return strconv.Itoa(m.version), nil
+}
+
+// History returns every commit, oldest first. The hash of a commit is its position in the history.
+func (m *Memory) History() ([]Commit, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(m.commits) == 0 {
+		return nil, ErrNotStarted
+	}
+
+	commits := make([]Commit, len(m.commits))
+	for i, c := range m.commits {
+		commits[i] = Commit{Hash: strconv.Itoa(i), Message: c.message, Date: c.date}
+	}
+	return commits, nil
+}
+
+// LoadGameAt returns a copy of the shared state of the game as it was at one of its commits
+func (m *Memory) LoadGameAt(hash string) (*game.Game, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	i, err := strconv.Atoi(hash)
+	if err != nil || i < 0 || i >= len(m.commits) {
+		return nil, fmt.Errorf("unknown commit: %s", hash)
+	}
+	return m.commits[i].game.View(""), nil
+}
+
+// LoadGameForAt returns a copy of the game as a team saw it at one of its commits
+func (m *Memory) LoadGameForAt(team, hash string) (*game.Game, error) {
+	if !game.ValidTeam(team) {
+		return nil, fmt.Errorf("invalid team: %s", team)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	i, err := strconv.Atoi(hash)
+	if err != nil || i < 0 || i >= len(m.commits) {
+		return nil, fmt.Errorf("unknown commit: %s", hash)
+	}
+	return m.commits[i].game.View(team), nil
+}
+
+// Moves returns every committed move, found by comparing each commit with the one before it
+func (m *Memory) Moves() ([]Move, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(m.commits) == 0 {
+		return nil, ErrNotStarted
+	}
+
+	var moves []Move
+	for i := 1; i < len(m.commits); i++ {
+		before, after := m.commits[i-1].game, m.commits[i].game
+		for _, team := range game.Teams {
+			for cell, shot := range after.Shots[team] {
+				if _, old := before.Shots[team][cell]; !old {
+					moves = append(moves, Move{Shot: shot, Hash: strconv.Itoa(i), Date: m.commits[i].date})
+				}
+			}
+		}
+	}
+
+	return numberMoves(moves, m.commits[len(m.commits)-1].game), nil
+}
+
+// RecordVerdict records the referee's verdict on a commit and commits it
+func (m *Memory) RecordVerdict(v Verdict) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.game == nil {
+		return ErrNotStarted
+	}
+
+	v.Date = time.Now()
+	m.verdicts = append(m.verdicts, v)
+	m.commit(verdictMessage(v))
+	return nil
+}
+
+// Verdicts returns every verdict the referee has recorded, oldest first
+func (m *Memory) Verdicts() ([]Verdict, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]Verdict(nil), m.verdicts...), nil
+}
+
+// Revert restores the game from before the latest commit, both fleets included, and commits it
+func (m *Memory) Revert(hash string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	i, err := strconv.Atoi(hash)
+	if err != nil || i <= 0 || i >= len(m.commits) {
+		return fmt.Errorf("unknown commit: %s", hash)
+	}
+	if i != len(m.commits)-1 {
+		return fmt.Errorf("only the latest commit can be reverted")
+	}
+
+	m.game = m.commits[i-1].game.Clone()
+	m.commit(revertMessage(hash))
+	return nil
+}
+
+// Audit checks the history of the game, comparing each snapshot with the one before it.
+// A Memory store has a single, linear history holding both fleets.
+func (m *Memory) Audit() ([]Anomaly, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(m.commits) == 0 {
+		return nil, ErrNotStarted
+	}
+
+	commits := make([]auditedCommit, len(m.commits))
+	for i, c := range m.commits {
+		commits[i] = auditedCommit{Commit: Commit{Hash: strconv.Itoa(i), Message: c.message, Date: c.date}, parents: 1}
+		if i > 0 {
+			commits[i].changes = snapshotChanges(m.commits[i-1].game, c.game)
+		}
+	}
+	return audit("memory", commits, false), nil
+}
+
+// Close implements the Store interface; there is nothing to release
+func (m *Memory) Close() error {
+	return nil
+}
+
+// update applies a change to the working state of the game. The state is left
+// unchanged if the change fails.
+func (m *Memory) update(change func(g *game.Game) error) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.change(change)
+}
+
+// change is update for callers that already hold m.mu
+func (m *Memory) change(change func(g *game.Game) error) error {
+	if m.game == nil {
+		return ErrNotStarted
+	}
+
+	g := m.game.Clone()
+	if err := change(g); err != nil {
+		return err
+	}
+	m.game = g
+	m.dirty = true
+	m.version++
+	return nil
+}
+
+// commit records a snapshot of the working state. The caller must hold m.mu.
+func (m *Memory) commit(message string) {
+	m.commits = append(m.commits, memoryCommit{message: message, date: time.Now(), game: m.game.Clone()})
+	m.dirty = false
+	m.version++
+}
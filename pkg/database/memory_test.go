@@ -0,0 +1,207 @@
+package database
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"battleship/pkg/game"
+)
+
+func TestMemoryGame(t *testing.T) {
+	m := NewMemory()
+	if _, err := m.LoadGame(); err == nil {
+		t.Fatal("LoadGame() before Initialize succeeded, want an error")
+	}
+
+	settings := oneShipSettings(10)
+	if err := m.Initialize(settings); err != nil {
+		t.Fatalf("Initialize() error = %v", err)
+	}
+
+	for _, team := range game.Teams {
+		if err := m.InsertCoin(team); err != nil {
+			t.Fatalf("InsertCoin(%s) error = %v", team, err)
+		}
+		if err := m.InsertShip(team+"_ships", "Destroyer", 0, 0, 2, game.Vertical); err != nil {
+			t.Fatalf("InsertShip(%s) error = %v", team, err)
+		}
+	}
+	if err := m.InsertCoin(game.Red); err == nil {
+		t.Error("InsertCoin(red) twice succeeded, want an error")
+	}
+
+	first, err := m.TossCoin()
+	if err != nil {
+		t.Fatalf("TossCoin() error = %v", err)
+	}
+	if err := m.Commit("Both teams have joined"); err != nil {
+		t.Fatalf("Commit() error = %v", err)
+	}
+
+	second := game.Opponent(first)
+	if _, err := m.FireShot(second, 0, 0); !errors.Is(err, game.ErrNotYourTurn) {
+		t.Errorf("FireShot() out of turn error = %v, want %v", err, game.ErrNotYourTurn)
+	}
+
+	moves := []struct {
+		team string
+		x, y int
+	}{
+		{first, 0, 0},
+		{second, 5, 5},
+		{first, 0, 1},
+	}
+	var shot *game.Shot
+	for _, move := range moves {
+		before, _ := m.Version()
+		shot, err = m.FireShot(move.team, move.x, move.y)
+		if err != nil {
+			t.Fatalf("FireShot(%s, %d, %d) error = %v", move.team, move.x, move.y, err)
+		}
+		if after, _ := m.Version(); after == before {
+			t.Errorf("Version() unchanged by FireShot(%s, %d, %d)", move.team, move.x, move.y)
+		}
+	}
+	if shot.Winner != first {
+		t.Errorf("final shot winner = %q, want %q", shot.Winner, first)
+	}
+
+	g, err := m.LoadGame()
+	if err != nil {
+		t.Fatalf("LoadGame() error = %v", err)
+	}
+	if g.Winner != first || g.Move != 3 {
+		t.Errorf("LoadGame() winner, move = %q, %d, want %q, 3", g.Winner, g.Move, first)
+	}
+
+	history, err := m.History()
+	if err != nil {
+		t.Fatalf("History() error = %v", err)
+	}
+	if len(history) != 6 || history[1].Message != "Both teams have joined" || history[4].Message != ShotCommitMessage(*shot) || history[5].Message != revealMessage {
+		t.Fatalf("History() = %+v, want six commits ending with the final shot and the reveal", history)
+	}
+
+	// The game as it was after the first move
+	g, err = m.LoadGameAt(history[2].Hash)
+	if err != nil {
+		t.Fatalf("LoadGameAt() error = %v", err)
+	}
+	if g.Move != 1 || g.Turn != second || g.Over() {
+		t.Errorf("LoadGameAt(%s) move, turn = %d, %q, want 1, %q and the game in progress", history[2].Hash, g.Move, g.Turn, second)
+	}
+	if _, err := m.LoadGameAt("99"); err == nil {
+		t.Error("LoadGameAt() of an unknown commit succeeded, want an error")
+	}
+}
+
+func TestMemoryRejectedChange(t *testing.T) {
+	m := NewMemory()
+	if err := m.Initialize(game.DefaultSettings()); err != nil {
+		t.Fatalf("Initialize() error = %v", err)
+	}
+	if err := m.InsertShip("red_ships", "Carrier", 0, 0, 5, game.Horizontal); err != nil {
+		t.Fatalf("InsertShip() error = %v", err)
+	}
+	before, _ := m.Version()
+
+	err := m.InsertShip("red_ships", "Battleship", 2, 0, 4, game.Vertical)
+	if !errors.Is(err, game.ErrInvalidPlacement) {
+		t.Errorf("InsertShip() over another ship error = %v, want %v", err, game.ErrInvalidPlacement)
+	}
+	if after, _ := m.Version(); after != before {
+		t.Errorf("Version() changed by a rejected InsertShip")
+	}
+
+	// Changing a loaded game must not change the store
+	g, err := m.LoadGameFor(game.Red)
+	if err != nil {
+		t.Fatalf("LoadGameFor(red) error = %v", err)
+	}
+	g.Boards[game.Red].Ships = nil
+	if err := m.ClearShips("blue_ships"); err != nil {
+		t.Fatalf("ClearShips() error = %v", err)
+	}
+	if g, _ = m.LoadGameFor(game.Red); len(g.Boards[game.Red].Ships) != 1 {
+		t.Errorf("red board has %d ships, want 1", len(g.Boards[game.Red].Ships))
+	}
+
+	// Neither the shared state nor the other team sees the red fleet
+	for _, team := range []string{"", game.Blue} {
+		if g, _ = m.LoadGameFor(team); len(g.Boards[game.Red].Ships) != 0 {
+			t.Errorf("LoadGameFor(%q) shows %d red ships, want none", team, len(g.Boards[game.Red].Ships))
+		}
+	}
+}
+
+func TestMemoryTakeback(t *testing.T) {
+	m := NewMemory()
+	if err := m.Initialize(oneShipSettings(5)); err != nil {
+		t.Fatalf("Initialize() error = %v", err)
+	}
+	for _, team := range game.Teams {
+		if err := m.InsertCoin(team); err != nil {
+			t.Fatalf("InsertCoin(%s) error = %v", team, err)
+		}
+		if err := m.InsertShip(team+"_ships", "Destroyer", 0, 0, 2, game.Horizontal); err != nil {
+			t.Fatalf("InsertShip(%s) error = %v", team, err)
+		}
+	}
+	first, _ := m.TossCoin()
+	second := game.Opponent(first)
+	if err := m.Commit("Both teams have joined"); err != nil {
+		t.Fatalf("Commit() error = %v", err)
+	}
+	if _, err := m.FireShot(first, 0, 0); err != nil {
+		t.Fatalf("FireShot() error = %v", err)
+	}
+	if _, err := m.FireShot(second, 3, 3); err != nil {
+		t.Fatalf("FireShot() error = %v", err)
+	}
+
+	// A declined request leaves the move in place
+	if err := m.RequestTakeback(second); err != nil {
+		t.Fatalf("RequestTakeback() error = %v", err)
+	}
+	if err := m.AnswerTakeback(second, true); !errors.Is(err, game.ErrNoTakeback) {
+		t.Errorf("AnswerTakeback() of its own request error = %v, want %v", err, game.ErrNoTakeback)
+	}
+	if err := m.AnswerTakeback(first, false); err != nil {
+		t.Fatalf("AnswerTakeback(decline) error = %v", err)
+	}
+	if g, _ := m.LoadGame(); g.Move != 2 || g.Takeback != "" {
+		t.Errorf("after declining move, takeback = %d, %q, want 2 and none", g.Move, g.Takeback)
+	}
+
+	// An accepted request restores the game from before the move
+	if err := m.RequestTakeback(second); err != nil {
+		t.Fatalf("RequestTakeback() error = %v", err)
+	}
+	if err := m.AnswerTakeback(first, true); err != nil {
+		t.Fatalf("AnswerTakeback(accept) error = %v", err)
+	}
+	g, err := m.LoadGame()
+	if err != nil {
+		t.Fatalf("LoadGame() error = %v", err)
+	}
+	if g.Move != 1 || g.Turn != second || len(g.Shots[second]) != 0 || g.Takeback != "" {
+		t.Errorf("after the takeback move, turn = %d, %q with %d shots, want 1, %q and none", g.Move, g.Turn, len(g.Shots[second]), second)
+	}
+
+	history, err := m.History()
+	if err != nil {
+		t.Fatalf("History() error = %v", err)
+	}
+	last := history[len(history)-1].Message
+	if want := "Team " + first + " agreed to take back move 2 by team " + second; last != want {
+		t.Errorf("last commit = %q, want %q", last, want)
+	}
+	if moves, _ := m.Moves(); len(moves) != 1 {
+		t.Errorf("Moves() after the takeback = %d moves, want 1", len(moves))
+	}
+	// The move undone stays in the history, as it does on Dolt
+	if moved := history[len(history)-5].Message; !strings.HasPrefix(moved, "Team "+second+" shot at (D3)") {
+		t.Errorf("commit before the first request = %q, want the move taken back", moved)
+	}
+}
@@ -0,0 +1,227 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"battleship/pkg/game"
+)
+
+// Errors returned by a Server when opening or creating a game
+var (
+	ErrGameNotFound = errors.New("game not found")
+	ErrGameExists   = errors.New("game already exists")
+)
+
+// ErrNotStarted is returned by a Store whose game has not been initialized
+var ErrNotStarted = errors.New("the game has not been started")
+
+// Server holds any number of games, each identified by its ID
+type Server interface {
+	// Open returns the store of an existing game
+	Open(gameID string) (Store, error)
+	// Create returns the store of a new, uninitialized game, replacing an existing game
+	// with the same ID only if force is set
+	Create(gameID string, force bool) (Store, error)
+	// Games returns the IDs of every game
+	Games() ([]string, error)
+}
+
+// Commit is a commit in the history of a game: the start of the game, a team joining or a move
+type Commit struct {
+	Hash    string
+	Message string
+	Date    time.Time
+}
+
+// ByReferee reports whether the commit was made by the referee to record a verdict or
+// revert an illegal commit
+func (c Commit) ByReferee() bool {
+	return strings.HasPrefix(c.Message, refereePrefix)
+}
+
+// TakesBack reports whether the commit undid a move its opponent agreed to take back
+func (c Commit) TakesBack() bool {
+	return strings.Contains(c.Message, " agreed to take back move ")
+}
+
+// Verdict is the referee's judgement of a commit
+type Verdict struct {
+	Hash     string    // commit that was judged
+	Message  string    // message of that commit
+	Problems []string  // rules broken by the commit; empty if it was legal
+	Reverted bool      // whether the referee reverted the commit
+	Date     time.Time // when the verdict was recorded; zero until it is
+}
+
+// Legal reports whether the commit broke no rules
+func (v Verdict) Legal() bool {
+	return len(v.Problems) == 0
+}
+
+// Move is a shot in the history of a game and the commit that recorded it
+type Move struct {
+	game.Shot
+	Hash string
+	Date time.Time
+}
+
+// Store is the storage used by the commands to start, join and play a game.
+// Database keeps the game in Dolt; Memory keeps it in memory for tests and offline play.
+type Store interface {
+	// Initialize creates a new game with the given settings and commits it
+	Initialize(settings game.Settings) error
+	// Created returns the time the game was started
+	Created() (time.Time, error)
+	// GetSettings returns the board dimensions and fleet chosen when the game was started
+	GetSettings() (game.Settings, error)
+	// LoadGame returns the shared state of the game: the teams, turn and every shot,
+	// but neither fleet
+	LoadGame() (*game.Game, error)
+	// LoadGameFor returns the game as a team sees it: the shared state and its own fleet
+	LoadGameFor(team string) (*game.Game, error)
+	// History returns the commits made since the game was started, oldest first
+	History() ([]Commit, error)
+	// LoadGameAt returns the shared state of the game as it was at one of its commits
+	LoadGameAt(hash string) (*game.Game, error)
+
+	// LoadGameForAt returns the game as a team saw it at one of its commits
+	LoadGameForAt(team, hash string) (*game.Game, error)
+	// Moves returns every move made so far with the commit that recorded it, in order
+	Moves() ([]Move, error)
+
+	// InsertCoin records that a team has joined the game
+	InsertCoin(team string) error
+	// TossCoin decides which team moves first once both teams have joined, returning
+	// an empty string while a team is still missing
+	TossCoin() (string, error)
+	// InsertShip places a named ship on a board such as red_ships
+	InsertShip(board, name string, x, y int, length int, direction game.Direction) error
+	// PlaceRandomShips places every unplaced ship of the fleet randomly for a team
+	PlaceRandomShips(team string) error
+	// ClearShips removes every ship from a board such as red_ships
+	ClearShips(board string) error
+	// CommitLayout publishes a salted hash of a team's fleet layout, keeping the salt
+	// secret until the end of the game
+	CommitLayout(team string) error
+
+	// GetTurn returns the team to play and the number of moves made so far
+	GetTurn() (string, int, error)
+	// FireShot makes and commits a complete move for a team. The move that wins the game
+	// is followed by a commit revealing both fleets and their salts.
+	FireShot(team string, x, y int) (*game.Shot, error)
+
+	// Resign ends the game with a win for the opponent of team and reveals both fleets
+	Resign(team string) error
+	// RequestTakeback asks to take back the move a team has just made
+	RequestTakeback(team string) error
+	// AnswerTakeback accepts or declines the takeback the opponent of team has asked for;
+	// accepting undoes the move with a new commit
+	AnswerTakeback(team string, accept bool) error
+
+	// RecordVerdict records and commits the referee's verdict on a commit
+	RecordVerdict(v Verdict) error
+	// Verdicts returns every verdict the referee has recorded, oldest first
+	Verdicts() ([]Verdict, error)
+	// Revert undoes the changes made by the latest commit with a new commit by the referee,
+	// putting back any fleet moved since the commit before it
+	Revert(hash string) error
+
+	// Audit walks the whole history of the game and returns every anomaly found in it
+	Audit() ([]Anomaly, error)
+
+	// Commit records every change since the last commit with the given message
+	Commit(message string) error
+
+	// Uncommitted reports whether there are changes for Commit to record
+	Uncommitted() (bool, error)
+	// Version returns a value that changes whenever the game does
+	Version() (string, error)
+	// Close releases the store
+	Close() error
+}
+
+var (
+	_ Store  = (*Database)(nil)
+	_ Store  = (*Memory)(nil)
+	_ Server = (*DoltServer)(nil)
+	_ Server = (*MemoryServer)(nil)
+)
+
+// DoltServer is a Dolt sql-server keeping each game on its own branch
+type DoltServer struct {
+	config Config
+}
+
+// NewDoltServer creates a DoltServer that connects using config
+func NewDoltServer(config Config) *DoltServer {
+	return &DoltServer{config: config}
+}
+
+// Open connects to an existing game
+func (s *DoltServer) Open(gameID string) (Store, error) {
+	return New(s.config, gameID)
+}
+
+// Create makes the branch for a new game
+func (s *DoltServer) Create(gameID string, force bool) (Store, error) {
+	return Create(s.config, gameID, force)
+}
+
+// Games returns the IDs of the games on the server, taken from the names of their branches
+func (s *DoltServer) Games() ([]string, error) {
+	return Games(s.config)
+}
+
+// MemoryServer keeps games in memory for as long as the program runs
+type MemoryServer struct {
+	mu    sync.Mutex
+	games map[string]*Memory
+}
+
+// NewMemoryServer creates a MemoryServer with no games
+func NewMemoryServer() *MemoryServer {
+	return &MemoryServer{games: make(map[string]*Memory)}
+}
+
+// Open returns an existing game
+func (s *MemoryServer) Open(gameID string) (Store, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	m, ok := s.games[gameID]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s (start it first)", ErrGameNotFound, gameID)
+	}
+	return m, nil
+}
+
+// Create returns a new in-memory game
+func (s *MemoryServer) Create(gameID string, force bool) (Store, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.games[gameID]; exists && !force {
+		return nil, fmt.Errorf("%w: %s (use --force to replace it)", ErrGameExists, gameID)
+	}
+	m := NewMemory()
+	s.games[gameID] = m
+	return m, nil
+}
+
+// Games returns the IDs of the games in memory, in alphabetical order
+func (s *MemoryServer) Games() ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ids := make([]string, 0, len(s.games))
+	for id := range s.games {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
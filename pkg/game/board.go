@@ -0,0 +1,172 @@
+package game
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+)
+
+// ErrInvalidPlacement is wrapped by the errors returned for ships that are off the board,
+// of a length the fleet does not have or overlapping another ship
+var ErrInvalidPlacement = errors.New("invalid ship placement")
+
+// maxPlacementAttempts bounds how many random positions are tried for each ship
+const maxPlacementAttempts = 10000
+
+// Coordinate is a cell on a board. X is the column, lettered from A, and Y is the row,
+// numbered from 0.
+type Coordinate struct {
+	X int
+	Y int
+}
+
+// String returns the coordinate as it is written by players, e.g. D3
+func (c Coordinate) String() string {
+	return fmt.Sprintf("%c%d", 'A'+c.X, c.Y)
+}
+
+// Direction represents the orientation of a ship
+type Direction bool
+
+const (
+	Vertical   Direction = true
+	Horizontal Direction = false
+)
+
+// Ship is a ship of a fleet. In a fleet definition only the name and length are set;
+// a ship placed on a board also has the position of its bow and its direction.
+type Ship struct {
+	Name      string
+	Length    int
+	Bow       Coordinate
+	Direction Direction // vertical ships run down the board, horizontal ones across it
+}
+
+// Cells returns the cells covered by the ship, from bow to stern
+func (s Ship) Cells() []Coordinate {
+	cells := make([]Coordinate, s.Length)
+	for i := range cells {
+		cells[i] = s.Bow
+		if s.Direction == Vertical {
+			cells[i].Y += i
+		} else {
+			cells[i].X += i
+		}
+	}
+	return cells
+}
+
+// Covers reports whether the ship covers the given cell
+func (s Ship) Covers(c Coordinate) bool {
+	if s.Direction == Vertical {
+		return c.X == s.Bow.X && c.Y >= s.Bow.Y && c.Y < s.Bow.Y+s.Length
+	}
+	return c.Y == s.Bow.Y && c.X >= s.Bow.X && c.X < s.Bow.X+s.Length
+}
+
+// Board is one team's board and the ships placed on it
+type Board struct {
+	Width  int
+	Height int
+	Ships  []Ship
+}
+
+// NewBoard creates an empty board of the given size
+func NewBoard(width, height int) *Board {
+	return &Board{Width: width, Height: height}
+}
+
+// Contains reports whether a cell is on the board
+func (b *Board) Contains(c Coordinate) bool {
+	return c.X >= 0 && c.X < b.Width && c.Y >= 0 && c.Y < b.Height
+}
+
+// ShipAt returns the ship covering the given cell, if any
+func (b *Board) ShipAt(c Coordinate) (Ship, bool) {
+	for _, ship := range b.Ships {
+		if ship.Covers(c) {
+			return ship, true
+		}
+	}
+	return Ship{}, false
+}
+
+// Ship returns the placed ship with the given name, if any
+func (b *Board) Ship(name string) (Ship, bool) {
+	for _, ship := range b.Ships {
+		if ship.Name == name {
+			return ship, true
+		}
+	}
+	return Ship{}, false
+}
+
+// CanPlace checks whether a ship of the given length can be placed with its bow at
+// (x, y), running down the board when vertical and across it when horizontal. Every
+// segment must lie on the board and must not overlap a ship already placed there. It
+// returns nil if the ship fits, or an error wrapping ErrInvalidPlacement if it does not.
+func (b *Board) CanPlace(x, y, length int, direction Direction) error {
+	bow := Coordinate{X: x, Y: y}
+
+	// Validate coordinates
+	if !b.Contains(bow) {
+		return fmt.Errorf("%w: coordinates out of bounds: (%d, %d)", ErrInvalidPlacement, x, y)
+	}
+	if length < 1 {
+		return fmt.Errorf("%w: invalid ship length: %d", ErrInvalidPlacement, length)
+	}
+
+	// Check if the stern is still on the board based on direction
+	switch direction {
+	case Vertical:
+		if y+length > b.Height {
+			return fmt.Errorf("%w: ship too long to fit at (%s) running down the board", ErrInvalidPlacement, bow)
+		}
+	case Horizontal:
+		if x+length > b.Width {
+			return fmt.Errorf("%w: ship too long to fit at (%s) running across the board", ErrInvalidPlacement, bow)
+		}
+	}
+
+	// Check that no segment overlaps a ship already on the board
+	ship := Ship{Length: length, Bow: bow, Direction: direction}
+	for _, cell := range ship.Cells() {
+		if _, occupied := b.ShipAt(cell); occupied {
+			return fmt.Errorf("%w: ship overlaps another ship at (%s)", ErrInvalidPlacement, cell)
+		}
+	}
+
+	return nil
+}
+
+// Place adds a ship to the board if it fits and no other ship has the same name
+func (b *Board) Place(ship Ship) error {
+	if _, exists := b.Ship(ship.Name); exists {
+		return fmt.Errorf("%w: %s has already been placed", ErrInvalidPlacement, ship.Name)
+	}
+	if err := b.CanPlace(ship.Bow.X, ship.Bow.Y, ship.Length, ship.Direction); err != nil {
+		return err
+	}
+
+	b.Ships = append(b.Ships, ship)
+	return nil
+}
+
+// PlaceRandom places the given ships at random positions on the board
+func (b *Board) PlaceRandom(ships []Ship) error {
+	for _, ship := range ships {
+		placed := false
+		for attempt := 0; attempt < maxPlacementAttempts && !placed; attempt++ {
+			// Generate random position and direction
+			ship.Bow = Coordinate{X: rand.Intn(b.Width), Y: rand.Intn(b.Height)}
+			ship.Direction = Direction(rand.Float32() < 0.5)
+
+			// Try again elsewhere if the ship does not fit there
+			placed = b.Place(ship) == nil
+		}
+		if !placed {
+			return fmt.Errorf("%w: could not find room for %s", ErrInvalidPlacement, ship.Name)
+		}
+	}
+	return nil
+}
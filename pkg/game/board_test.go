@@ -0,0 +1,180 @@
+package game
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestShipCells(t *testing.T) {
+	tests := []struct {
+		name string
+		ship Ship
+		want []Coordinate
+	}{
+		{
+			name: "Horizontal",
+			ship: Ship{Length: 3, Bow: Coordinate{X: 2, Y: 4}, Direction: Horizontal},
+			want: []Coordinate{{2, 4}, {3, 4}, {4, 4}},
+		},
+		{
+			name: "Vertical",
+			ship: Ship{Length: 2, Bow: Coordinate{X: 7, Y: 0}, Direction: Vertical},
+			want: []Coordinate{{7, 0}, {7, 1}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.ship.Cells()
+			if len(got) != len(tt.want) {
+				t.Fatalf("Cells() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("Cells() = %v, want %v", got, tt.want)
+				}
+				if !tt.ship.Covers(got[i]) {
+					t.Errorf("Covers(%v) = false for one of the ship's cells", got[i])
+				}
+			}
+			if tt.ship.Covers(Coordinate{X: 0, Y: 0}) {
+				t.Error("Covers(A0) = true for a cell outside the ship")
+			}
+		})
+	}
+}
+
+func TestBoardCanPlace(t *testing.T) {
+	board := NewBoard(10, 10)
+	if err := board.Place(Ship{Name: "Destroyer", Length: 2, Bow: Coordinate{X: 4, Y: 4}, Direction: Horizontal}); err != nil {
+		t.Fatalf("Place() error = %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		x, y      int
+		length    int
+		direction Direction
+		wantErr   bool
+	}{
+		// Top-left corner
+		{name: "Top-left corner horizontal", x: 0, y: 0, length: 5, direction: Horizontal},
+		{name: "Top-left corner vertical", x: 0, y: 0, length: 5, direction: Vertical},
+		{name: "Left of the board", x: -1, y: 0, length: 2, direction: Horizontal, wantErr: true},
+		{name: "Above the board", x: 0, y: -1, length: 2, direction: Vertical, wantErr: true},
+
+		// Top edge and top-right corner
+		{name: "Top edge ending in last column", x: 5, y: 0, length: 5, direction: Horizontal},
+		{name: "Top edge past last column", x: 6, y: 0, length: 5, direction: Horizontal, wantErr: true},
+		{name: "Top-right corner horizontal", x: 9, y: 0, length: 1, direction: Horizontal},
+		{name: "Top-right corner horizontal too long", x: 9, y: 0, length: 2, direction: Horizontal, wantErr: true},
+		{name: "Top-right corner vertical", x: 9, y: 0, length: 5, direction: Vertical},
+		{name: "Right of the board", x: 10, y: 0, length: 1, direction: Vertical, wantErr: true},
+
+		// Right edge and bottom-right corner
+		{name: "Right edge ending in last row", x: 9, y: 5, length: 5, direction: Vertical},
+		{name: "Right edge past last row", x: 9, y: 6, length: 5, direction: Vertical, wantErr: true},
+		{name: "Bottom-right corner vertical", x: 9, y: 9, length: 1, direction: Vertical},
+		{name: "Bottom-right corner vertical too long", x: 9, y: 9, length: 2, direction: Vertical, wantErr: true},
+		{name: "Bottom-right corner horizontal too long", x: 9, y: 9, length: 2, direction: Horizontal, wantErr: true},
+
+		// Bottom edge and bottom-left corner
+		{name: "Bottom edge ending in last column", x: 5, y: 9, length: 5, direction: Horizontal},
+		{name: "Bottom edge past last column", x: 6, y: 9, length: 5, direction: Horizontal, wantErr: true},
+		{name: "Bottom-left corner horizontal", x: 0, y: 9, length: 5, direction: Horizontal},
+		{name: "Bottom-left corner vertical too long", x: 0, y: 9, length: 2, direction: Vertical, wantErr: true},
+		{name: "Below the board", x: 0, y: 10, length: 1, direction: Horizontal, wantErr: true},
+
+		// Left edge
+		{name: "Left edge ending in last row", x: 0, y: 5, length: 5, direction: Vertical},
+		{name: "Left edge past last row", x: 0, y: 6, length: 5, direction: Vertical, wantErr: true},
+
+		// Ships spanning the whole board
+		{name: "Full width in last row", x: 0, y: 9, length: 10, direction: Horizontal},
+		{name: "Full height in last column", x: 9, y: 0, length: 10, direction: Vertical},
+		{name: "Longer than the board", x: 0, y: 0, length: 11, direction: Horizontal, wantErr: true},
+		{name: "Zero length", x: 0, y: 0, length: 0, direction: Horizontal, wantErr: true},
+
+		// Overlaps with the destroyer at E4-F4
+		{name: "Overlapping bow", x: 5, y: 4, length: 3, direction: Horizontal, wantErr: true},
+		{name: "Overlapping stern", x: 2, y: 4, length: 3, direction: Horizontal, wantErr: true},
+		{name: "Crossing", x: 5, y: 2, length: 4, direction: Vertical, wantErr: true},
+		{name: "Touching", x: 6, y: 4, length: 3, direction: Horizontal},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := board.CanPlace(tt.x, tt.y, tt.length, tt.direction)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("CanPlace() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidPlacement) {
+				t.Errorf("CanPlace() error = %v, want it to wrap %v", err, ErrInvalidPlacement)
+			}
+		})
+	}
+}
+
+func TestBoardCanPlaceRectangular(t *testing.T) {
+	board := NewBoard(12, 6)
+
+	tests := []struct {
+		name      string
+		x, y      int
+		length    int
+		direction Direction
+		wantErr   bool
+	}{
+		{name: "Ending in column L", x: 7, y: 0, length: 5, direction: Horizontal},
+		{name: "Past column L", x: 8, y: 0, length: 5, direction: Horizontal, wantErr: true},
+		{name: "Ending in row 5", x: 11, y: 1, length: 5, direction: Vertical},
+		{name: "Past row 5", x: 11, y: 2, length: 5, direction: Vertical, wantErr: true},
+		{name: "Full width", x: 0, y: 5, length: 12, direction: Horizontal},
+		{name: "Taller than the board", x: 0, y: 0, length: 7, direction: Vertical, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := board.CanPlace(tt.x, tt.y, tt.length, tt.direction)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("CanPlace() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestBoardPlaceDuplicate(t *testing.T) {
+	board := NewBoard(10, 10)
+	ship := Ship{Name: "Destroyer", Length: 2, Bow: Coordinate{X: 0, Y: 0}, Direction: Horizontal}
+	if err := board.Place(ship); err != nil {
+		t.Fatalf("Place() error = %v", err)
+	}
+
+	ship.Bow = Coordinate{X: 0, Y: 5}
+	if err := board.Place(ship); !errors.Is(err, ErrInvalidPlacement) {
+		t.Errorf("Place() of a second %s error = %v, want %v", ship.Name, err, ErrInvalidPlacement)
+	}
+}
+
+func TestBoardPlaceRandom(t *testing.T) {
+	board := NewBoard(10, 10)
+	if err := board.PlaceRandom(DefaultFleet); err != nil {
+		t.Fatalf("PlaceRandom() error = %v", err)
+	}
+	if len(board.Ships) != len(DefaultFleet) {
+		t.Fatalf("PlaceRandom() placed %d ships, want %d", len(board.Ships), len(DefaultFleet))
+	}
+
+	occupied := make(map[Coordinate]string)
+	for _, ship := range board.Ships {
+		for _, cell := range ship.Cells() {
+			if !board.Contains(cell) {
+				t.Errorf("%s has a segment off the board at %s", ship.Name, cell)
+			}
+			if other, ok := occupied[cell]; ok {
+				t.Errorf("%s overlaps %s at %s", ship.Name, other, cell)
+			}
+			occupied[cell] = ship.Name
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package game
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Commitment is a salted hash of a team's fleet layout, published when the team joins so
+// that the layout cannot be changed unnoticed during the game. The salt is kept secret
+// until the game ends, when it is revealed along with the layout.
+type Commitment struct {
+	Hash string
+	Salt string // empty while the salt is secret
+}
+
+// NewSalt returns a random salt for a commitment
+func NewSalt() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate salt: %v", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// LayoutHash returns the hex-encoded SHA-256 hash of a salt and a fleet layout. The ships
+// are hashed in order of name, so the order they were placed in does not matter.
+func LayoutHash(ships []Ship, salt string) string {
+	sorted := append([]Ship(nil), ships...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+
+	var layout strings.Builder
+	layout.WriteString(salt + "\n")
+	for _, ship := range sorted {
+		direction := "h"
+		if ship.Direction == Vertical {
+			direction = "v"
+		}
+		fmt.Fprintf(&layout, "%s %d %s %s\n", ship.Name, ship.Length, ship.Bow, direction)
+	}
+
+	sum := sha256.Sum256([]byte(layout.String()))
+	return hex.EncodeToString(sum[:])
+}
+
+// Matches reports whether the commitment's salt has been revealed and hashes with ships to
+// the committed hash
+func (c Commitment) Matches(ships []Ship) bool {
+	return c.Salt != "" && LayoutHash(ships, c.Salt) == c.Hash
+}
+
+// CommitLayout commits a team to the layout of its fully placed fleet with a new salt
+func (g *Game) CommitLayout(team string) error {
+	board, ok := g.Boards[team]
+	if !ok {
+		return fmt.Errorf("invalid team: %s", team)
+	}
+	if len(board.Ships) != len(g.Settings.Fleet) {
+		return fmt.Errorf("the %s fleet has not been fully placed", team)
+	}
+
+	salt, err := NewSalt()
+	if err != nil {
+		return err
+	}
+	g.Commitments[team] = Commitment{Hash: LayoutHash(board.Ships, salt), Salt: salt}
+	return nil
+}
+
+// Reveal makes both fleets and the salts of their commitments public once the game is over
+func (g *Game) Reveal() error {
+	if !g.Over() {
+		return fmt.Errorf("the fleets cannot be revealed before the game is over")
+	}
+	g.Revealed = true
+	return nil
+}
+
+// CheckShots replays shots in order against g's fleets and returns an error describing
+// the first shot whose recorded result is not what the fleets give
+func (g *Game) CheckShots(shots []Shot) error {
+	replay := New(g.Settings)
+	for _, team := range Teams {
+		replay.Boards[team].Ships = append([]Ship(nil), g.Boards[team].Ships...)
+	}
+
+	for _, recorded := range shots {
+		replay.Turn = recorded.Team
+		shot, err := replay.Fire(recorded.Team, recorded.Target)
+		if err != nil {
+			return fmt.Errorf("move %d by team %s at %s: %v", recorded.Move, recorded.Team, recorded.Target, err)
+		}
+		if shot.Hit != recorded.Hit || shot.Sunk != recorded.Sunk {
+			return fmt.Errorf("move %d by team %s at %s was recorded as a %s but the fleet gives a %s",
+				recorded.Move, recorded.Team, recorded.Target, recorded.Outcome(), shot.Outcome())
+		}
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package game
+
+import "testing"
+
+func TestCommitLayout(t *testing.T) {
+	g := newTestGame(t)
+	if err := g.CommitLayout(Red); err != nil {
+		t.Fatalf("CommitLayout() error = %v", err)
+	}
+	commitment := g.Commitments[Red]
+	if len(commitment.Hash) != 64 || commitment.Salt == "" {
+		t.Fatalf("CommitLayout() = %+v, want a SHA-256 hash and a salt", commitment)
+	}
+	if !commitment.Matches(g.Boards[Red].Ships) {
+		t.Error("Matches() of the committed layout = false, want true")
+	}
+
+	// The salt is hidden from the other team and spectators until the game ends
+	if c := g.View(Blue).Commitments[Red]; c.Hash != commitment.Hash || c.Salt != "" {
+		t.Errorf("View(blue) commitment = %+v, want the hash without the salt", c)
+	}
+	if c := g.View(Red).Commitments[Red]; c.Salt != commitment.Salt {
+		t.Errorf("View(red) salt = %q, want %q", c.Salt, commitment.Salt)
+	}
+	if err := g.Reveal(); err == nil {
+		t.Error("Reveal() before the game ended succeeded, want an error")
+	}
+	if err := g.Resign(Blue); err != nil {
+		t.Fatalf("Resign() error = %v", err)
+	}
+	if err := g.Reveal(); err != nil {
+		t.Fatalf("Reveal() error = %v", err)
+	}
+	if v := g.View(""); v.Commitments[Red].Salt == "" || len(v.Boards[Red].Ships) != 1 {
+		t.Error("View() after Reveal() hides the red fleet or salt")
+	}
+
+	tests := []struct {
+		name  string
+		ships []Ship
+		salt  string
+		want  bool
+	}{
+		{"committed layout", g.Boards[Red].Ships, commitment.Salt, true},
+		{"moved ship", []Ship{{Name: "Destroyer", Length: 2, Bow: Coordinate{X: 0, Y: 1}}}, commitment.Salt, false},
+		{"turned ship", []Ship{{Name: "Destroyer", Length: 2, Direction: Vertical}}, commitment.Salt, false},
+		{"other salt", g.Boards[Red].Ships, "0123", false},
+		{"hidden salt", g.Boards[Red].Ships, "", false},
+	}
+
+	for _, tt := range tests {
+		c := Commitment{Hash: commitment.Hash, Salt: tt.salt}
+		if got := c.Matches(tt.ships); got != tt.want {
+			t.Errorf("%s: Matches() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestLayoutHashOrder(t *testing.T) {
+	ships := []Ship{
+		{Name: "Cruiser", Length: 3, Bow: Coordinate{X: 2, Y: 2}},
+		{Name: "Destroyer", Length: 2, Bow: Coordinate{X: 0, Y: 0}, Direction: Vertical},
+	}
+	reversed := []Ship{ships[1], ships[0]}
+	if LayoutHash(ships, "salt") != LayoutHash(reversed, "salt") {
+		t.Error("LayoutHash() depends on the order the ships were placed in")
+	}
+}
+
+func TestCheckShots(t *testing.T) {
+	g := newTestGame(t)
+	shots := []Shot{
+		{Team: Red, Target: Coordinate{X: 0, Y: 0}, Move: 1, Hit: true},
+		{Team: Blue, Target: Coordinate{X: 4, Y: 4}, Move: 2},
+		{Team: Red, Target: Coordinate{X: 1, Y: 0}, Move: 3, Hit: true, Sunk: "Destroyer"},
+	}
+	if err := g.CheckShots(shots); err != nil {
+		t.Errorf("CheckShots() of the recorded shots error = %v", err)
+	}
+
+	// A miss recorded where the fleet has a ship shows the fleet was changed
+	shots[1].Target = Coordinate{X: 1, Y: 0}
+	if err := g.CheckShots(shots); err == nil {
+		t.Error("CheckShots() with a miss on a ship succeeded, want an error")
+	}
+}
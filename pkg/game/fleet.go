@@ -0,0 +1,170 @@
+package game
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Fleet is the list of ships each team must place
+type Fleet []Ship
+
+// Settings holds the rules chosen when a game is started
+type Settings struct {
+	Fleet  Fleet
+	Width  int // number of columns, lettered from A
+	Height int // number of rows, numbered from 0
+}
+
+// Limits on the board dimensions. Columns are lettered, so there can be at most 26.
+const (
+	MinBoardSize = 5
+	MaxBoardSize = 26
+)
+
+// DefaultFleet is the classic fleet placed by each team
+var DefaultFleet = Fleet{
+	{Name: "Carrier", Length: 5},
+	{Name: "Battleship", Length: 4},
+	{Name: "Cruiser", Length: 3},
+	{Name: "Submarine", Length: 3},
+	{Name: "Destroyer", Length: 2},
+}
+
+// Fleets are the named fleet compositions that can be chosen when a game is started
+var Fleets = map[string]Fleet{
+	"classic": DefaultFleet,
+	"hasbro": {
+		{Name: "Carrier", Length: 5},
+		{Name: "Battleship", Length: 4},
+		{Name: "Destroyer", Length: 3},
+		{Name: "Submarine", Length: 3},
+		{Name: "Patrol Boat", Length: 2},
+	},
+	"russian": namedFleet([]int{4, 3, 3, 2, 2, 2, 1, 1, 1, 1}),
+}
+
+// DefaultSettings returns the settings used when none are given: the classic fleet on a 10x10 board
+func DefaultSettings() Settings {
+	return Settings{Fleet: DefaultFleet, Width: 10, Height: 10}
+}
+
+// Validate checks that the board dimensions are within limits and that the fleet can be
+// placed on the board
+func (s Settings) Validate() error {
+	if s.Width < MinBoardSize || s.Width > MaxBoardSize || s.Height < MinBoardSize || s.Height > MaxBoardSize {
+		return fmt.Errorf("invalid board size %dx%d: width and height must be between %d and %d", s.Width, s.Height, MinBoardSize, MaxBoardSize)
+	}
+	if err := s.Fleet.Validate(); err != nil {
+		return err
+	}
+
+	longest := s.Width
+	if s.Height > longest {
+		longest = s.Height
+	}
+
+	cells := 0
+	for _, ship := range s.Fleet {
+		if ship.Length > longest {
+			return fmt.Errorf("%s is %d cells long and does not fit on a %dx%d board", ship.Name, ship.Length, s.Width, s.Height)
+		}
+		cells += ship.Length
+	}
+
+	// Leave room for random placement to succeed
+	if cells*2 > s.Width*s.Height {
+		return fmt.Errorf("fleet occupies %d cells: at most half of the %dx%d board may be covered", cells, s.Width, s.Height)
+	}
+
+	return nil
+}
+
+// ParseFleet returns the fleet described by spec, which is either the name of one of
+// the Fleets or a comma separated list of ship lengths such as "5,4,3,3,2"
+func ParseFleet(spec string) (Fleet, error) {
+	if fleet, ok := Fleets[strings.ToLower(spec)]; ok {
+		return fleet, nil
+	}
+
+	var lengths []int
+	for _, field := range strings.Split(spec, ",") {
+		length, err := strconv.Atoi(strings.TrimSpace(field))
+		if err != nil {
+			return nil, fmt.Errorf("unknown fleet %q: expected classic, hasbro, russian or a list of ship lengths", spec)
+		}
+		lengths = append(lengths, length)
+	}
+
+	fleet := namedFleet(lengths)
+	if err := fleet.Validate(); err != nil {
+		return nil, err
+	}
+	return fleet, nil
+}
+
+// Validate checks that a fleet has at least one ship and that every ship has a unique
+// name and a positive length. Whether the fleet fits is checked by Settings.Validate.
+func (f Fleet) Validate() error {
+	if len(f) == 0 {
+		return fmt.Errorf("fleet must contain at least one ship")
+	}
+
+	names := make(map[string]bool)
+	for _, ship := range f {
+		if ship.Length < 1 {
+			return fmt.Errorf("%s has invalid length %d: ships must be at least 1 cell long", ship.Name, ship.Length)
+		}
+		if names[ship.Name] {
+			return fmt.Errorf("fleet contains more than one ship named %s", ship.Name)
+		}
+		names[ship.Name] = true
+	}
+
+	return nil
+}
+
+// Ship returns the ship of the fleet with the given name
+func (f Fleet) Ship(name string) (Ship, bool) {
+	for _, ship := range f {
+		if ship.Name == name {
+			return ship, true
+		}
+	}
+	return Ship{}, false
+}
+
+// namedFleet names ships of the given lengths by their class, numbering them when a
+// class appears more than once, e.g. "Cruiser 1" and "Cruiser 2"
+func namedFleet(lengths []int) Fleet {
+	classes := map[int]string{
+		1: "Boat",
+		2: "Destroyer",
+		3: "Cruiser",
+		4: "Battleship",
+		5: "Carrier",
+	}
+	className := func(length int) string {
+		if class, ok := classes[length]; ok {
+			return class
+		}
+		return "Ship"
+	}
+
+	counts := make(map[string]int)
+	for _, length := range lengths {
+		counts[className(length)]++
+	}
+
+	fleet := make(Fleet, 0, len(lengths))
+	seen := make(map[string]int)
+	for _, length := range lengths {
+		name := className(length)
+		if counts[name] > 1 {
+			seen[name]++
+			name = fmt.Sprintf("%s %d", name, seen[name])
+		}
+		fleet = append(fleet, Ship{Name: name, Length: length})
+	}
+	return fleet
+}
@@ -0,0 +1,106 @@
+package game
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseFleet(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    Fleet
+		wantErr bool
+	}{
+		{
+			spec: "classic",
+			want: DefaultFleet,
+		},
+		{
+			spec: "Hasbro",
+			want: Fleets["hasbro"],
+		},
+		{
+			spec: "russian",
+			want: Fleet{
+				{Name: "Battleship", Length: 4},
+				{Name: "Cruiser 1", Length: 3}, {Name: "Cruiser 2", Length: 3},
+				{Name: "Destroyer 1", Length: 2}, {Name: "Destroyer 2", Length: 2}, {Name: "Destroyer 3", Length: 2},
+				{Name: "Boat 1", Length: 1}, {Name: "Boat 2", Length: 1}, {Name: "Boat 3", Length: 1}, {Name: "Boat 4", Length: 1},
+			},
+		},
+		{
+			spec: "5, 3,3",
+			want: Fleet{{Name: "Carrier", Length: 5}, {Name: "Cruiser 1", Length: 3}, {Name: "Cruiser 2", Length: 3}},
+		},
+		{
+			spec: "7",
+			want: Fleet{{Name: "Ship", Length: 7}},
+		},
+		{spec: "armada", wantErr: true},
+		{spec: "5,0", wantErr: true},
+		{spec: "5,x", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			got, err := ParseFleet(tt.spec)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseFleet(%q) error = %v, wantErr %v", tt.spec, err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseFleet(%q) = %v, want %v", tt.spec, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSettingsValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings Settings
+		wantErr  bool
+	}{
+		{
+			name:     "Default settings",
+			settings: DefaultSettings(),
+		},
+		{
+			name:     "Wide board",
+			settings: Settings{Fleet: Fleet{{Name: "Ship", Length: 12}}, Width: 26, Height: 5},
+		},
+		{
+			name:     "Board too small",
+			settings: Settings{Fleet: DefaultFleet, Width: 4, Height: 10},
+			wantErr:  true,
+		},
+		{
+			name:     "Board too wide",
+			settings: Settings{Fleet: DefaultFleet, Width: 27, Height: 10},
+			wantErr:  true,
+		},
+		{
+			name:     "Ship longer than the board",
+			settings: Settings{Fleet: Fleet{{Name: "Ship", Length: 11}}, Width: 10, Height: 10},
+			wantErr:  true,
+		},
+		{
+			name:     "Fleet covers more than half the board",
+			settings: Settings{Fleet: DefaultFleet, Width: 5, Height: 5},
+			wantErr:  true,
+		},
+		{
+			name:     "Empty fleet",
+			settings: Settings{Width: 10, Height: 10},
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.settings.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
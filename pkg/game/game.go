@@ -0,0 +1,376 @@
+// Package game implements the rules of battleship in memory: placing ships, resolving
+// shots, sinking ships, turn order and victory. It knows nothing about storage; the
+// database package loads a Game, applies a rule to it and persists the result.
+package game
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+)
+
+// Teams
+const (
+	Red  = "red"
+	Blue = "blue"
+)
+
+// Teams lists both teams in a fixed order
+var Teams = []string{Red, Blue}
+
+// Game statuses, as stored by the database
+const (
+	StatusInProgress = "in_progress"
+	StatusRedWon     = "red_won"
+	StatusBlueWon    = "blue_won"
+)
+
+// Errors returned by Fire when a shot is not allowed
+var (
+	ErrAlreadyShot = errors.New("that cell has already been shot at")
+	ErrNotYourTurn = errors.New("it is not your turn")
+	ErrGameOver    = errors.New("the game is over")
+	ErrOffBoard    = errors.New("that cell is not on the board")
+)
+
+// Errors returned when asking for or answering a takeback
+var (
+	ErrNothingToTakeBack = errors.New("the last move was not yours to take back")
+	ErrTakebackPending   = errors.New("a takeback has already been requested")
+	ErrNoTakeback        = errors.New("the other team has not asked for a takeback")
+)
+
+// Opponent returns the team playing against the given team
+func Opponent(team string) string {
+	if team == Blue {
+		return Red
+	}
+	return Blue
+}
+
+// ValidTeam reports whether team names one of the two teams
+func ValidTeam(team string) bool {
+	return team == Red || team == Blue
+}
+
+// Shot is a shot fired by a team and its outcome
+type Shot struct {
+	Team   string     // team that fired the shot
+	Target Coordinate // cell that was shot at
+	Move   int        // move number of the shot, starting at 1; zero when not known
+	Hit    bool       // whether the shot hit a ship
+	Sunk   string     // name of the ship sunk by the shot, if any
+	Winner string     // team that won the game with this shot, if any
+}
+
+// Outcome returns a short description of the shot: "miss", "hit" or "hit and sunk <ship>"
+func (s Shot) Outcome() string {
+	switch {
+	case s.Sunk != "":
+		return fmt.Sprintf("hit and sunk %s", s.Sunk)
+	case s.Hit:
+		return "hit"
+	default:
+		return "miss"
+	}
+}
+
+// Game holds the complete state of a game
+type Game struct {
+	Settings    Settings
+	Boards      map[string]*Board              // ships placed by each team
+	Shots       map[string]map[Coordinate]Shot // shots fired by each team
+	Joined      map[string]bool                // teams that have joined the game
+	Commitments map[string]Commitment          // each team's commitment to its fleet layout
+	FirstTeam   string                         // team that won the coin toss
+	Turn        string                         // team to play; empty until the coin is tossed
+	Move        int                            // number of moves made so far
+	Winner      string                         // team that won the game, if it is over
+	Takeback    string                         // team that has asked to take back its last move, if any
+	Resigned    string                         // team that resigned, if the game ended that way
+	Revealed    bool                           // whether both fleets have been made public
+}
+
+// New creates a game with empty boards for both teams
+func New(settings Settings) *Game {
+	g := &Game{
+		Settings:    settings,
+		Boards:      make(map[string]*Board),
+		Shots:       make(map[string]map[Coordinate]Shot),
+		Joined:      make(map[string]bool),
+		Commitments: make(map[string]Commitment),
+	}
+	for _, team := range Teams {
+		g.Boards[team] = NewBoard(settings.Width, settings.Height)
+		g.Shots[team] = make(map[Coordinate]Shot)
+	}
+	return g
+}
+
+// Status returns the status of the game: in progress or won by one of the teams
+func (g *Game) Status() string {
+	switch g.Winner {
+	case Red:
+		return StatusRedWon
+	case Blue:
+		return StatusBlueWon
+	default:
+		return StatusInProgress
+	}
+}
+
+// Over reports whether the game has been won
+func (g *Game) Over() bool {
+	return g.Winner != ""
+}
+
+// Join records a team joining the game
+func (g *Game) Join(team string) error {
+	if !ValidTeam(team) {
+		return fmt.Errorf("invalid team: %s", team)
+	}
+	if g.Joined[team] {
+		return fmt.Errorf("the %s team has already joined", team)
+	}
+	g.Joined[team] = true
+	return nil
+}
+
+// TossCoin decides which team moves first once both teams have joined. It returns the
+// team that won the toss, or an empty string if a team is still missing or the coin
+// has already been tossed.
+func (g *Game) TossCoin() string {
+	if g.FirstTeam != "" || !g.Joined[Red] || !g.Joined[Blue] {
+		return ""
+	}
+
+	first := Teams[rand.Intn(len(Teams))]
+	g.FirstTeam, g.Turn = first, first
+	return first
+}
+
+// PlaceShip places a ship on a team's board. The ship must be one of the ships of the
+// fleet, with the same name and length, fit on the board and not overlap any other ship.
+func (g *Game) PlaceShip(team string, ship Ship) error {
+	board, ok := g.Boards[team]
+	if !ok {
+		return fmt.Errorf("invalid team: %s", team)
+	}
+	want, ok := g.Settings.Fleet.Ship(ship.Name)
+	if !ok {
+		return fmt.Errorf("%w: %s is not part of the fleet", ErrInvalidPlacement, ship.Name)
+	}
+	if ship.Length != want.Length {
+		return fmt.Errorf("%w: invalid ship length for %s: %d, want %d", ErrInvalidPlacement, ship.Name, ship.Length, want.Length)
+	}
+	return board.Place(ship)
+}
+
+// PlaceRandomShips places every ship of the fleet not yet on a team's board at random
+func (g *Game) PlaceRandomShips(team string) error {
+	board, ok := g.Boards[team]
+	if !ok {
+		return fmt.Errorf("invalid team: %s", team)
+	}
+
+	var unplaced []Ship
+	for _, ship := range g.Settings.Fleet {
+		if _, placed := board.Ship(ship.Name); !placed {
+			unplaced = append(unplaced, ship)
+		}
+	}
+	return board.PlaceRandom(unplaced)
+}
+
+// Fire resolves a shot by a team at a cell of the opponent's board and passes the turn.
+// Shots that break the rules are rejected with ErrGameOver, ErrNotYourTurn, ErrOffBoard
+// or ErrAlreadyShot and leave the game unchanged.
+func (g *Game) Fire(team string, target Coordinate) (Shot, error) {
+	if g.Over() {
+		return Shot{}, ErrGameOver
+	}
+	if g.Turn != team {
+		return Shot{}, ErrNotYourTurn
+	}
+
+	opponent := Opponent(team)
+	if !g.Boards[opponent].Contains(target) {
+		return Shot{}, ErrOffBoard
+	}
+	if _, shot := g.Shots[team][target]; shot {
+		return Shot{}, ErrAlreadyShot
+	}
+
+	g.Move++
+	shot := Shot{Team: team, Target: target, Move: g.Move}
+	ship, hit := g.Boards[opponent].ShipAt(target)
+	shot.Hit = hit
+	g.Shots[team][target] = shot
+
+	if hit && g.IsSunk(opponent, ship.Name) {
+		shot.Sunk = ship.Name
+	}
+	if g.FleetDestroyed(opponent) {
+		g.Winner = team
+		shot.Winner = team
+	}
+
+	g.Shots[team][target] = shot
+	g.Turn = opponent
+	g.Takeback = ""
+	return shot, nil
+}
+
+// Resign ends the game with a win for the opponent of the team that resigns
+func (g *Game) Resign(team string) error {
+	if !ValidTeam(team) {
+		return fmt.Errorf("invalid team: %s", team)
+	}
+	if g.Over() {
+		return ErrGameOver
+	}
+
+	g.Winner = Opponent(team)
+	g.Resigned = team
+	g.Takeback = ""
+	return nil
+}
+
+// RequestTakeback records a team asking to take back the move it has just made.
+// The request lapses if the opponent moves instead of answering it.
+func (g *Game) RequestTakeback(team string) error {
+	if g.Over() {
+		return ErrGameOver
+	}
+	if g.Takeback != "" {
+		return ErrTakebackPending
+	}
+	if g.Move == 0 || g.Turn != Opponent(team) {
+		return ErrNothingToTakeBack
+	}
+
+	g.Takeback = team
+	return nil
+}
+
+// AnswerTakeback clears the takeback the opponent of team has asked for. Whether the move
+// is then taken back is up to the caller, which restores the game from before the move.
+func (g *Game) AnswerTakeback(team string) error {
+	if g.Takeback == "" || g.Takeback != Opponent(team) {
+		return ErrNoTakeback
+	}
+
+	g.Takeback = ""
+	return nil
+}
+
+// IsSunk reports whether every cell of the named ship on a team's board has been hit
+func (g *Game) IsSunk(team, name string) bool {
+	ship, ok := g.Boards[team].Ship(name)
+	if !ok {
+		return false
+	}
+
+	shots := g.Shots[Opponent(team)]
+	for _, cell := range ship.Cells() {
+		if _, shot := shots[cell]; !shot {
+			return false
+		}
+	}
+	return true
+}
+
+// SunkShips returns the names of the ships sunk on a team's board, in alphabetical order
+func (g *Game) SunkShips(team string) []string {
+	var sunk []string
+	for _, ship := range g.Boards[team].Ships {
+		if g.IsSunk(team, ship.Name) {
+			sunk = append(sunk, ship.Name)
+		}
+	}
+	sort.Strings(sunk)
+	return sunk
+}
+
+// FleetDestroyed reports whether every ship of the fleet has been sunk on a team's board
+func (g *Game) FleetDestroyed(team string) bool {
+	return len(g.Settings.Fleet) > 0 && len(g.SunkShips(team)) == len(g.Settings.Fleet)
+}
+
+// ShipCells returns a team's ship cells for display: "S" for an undamaged segment and
+// "H" for one that has been hit
+func (g *Game) ShipCells(team string) map[Coordinate]string {
+	cells := make(map[Coordinate]string)
+	shots := g.Shots[Opponent(team)]
+	for _, ship := range g.Boards[team].Ships {
+		for _, cell := range ship.Cells() {
+			cells[cell] = "S"
+			if _, shot := shots[cell]; shot {
+				cells[cell] = "H"
+			}
+		}
+	}
+	return cells
+}
+
+// ShotCells returns the shots fired by a team for display: "H" for a hit and "M" for a miss
+func (g *Game) ShotCells(team string) map[Coordinate]string {
+	cells := make(map[Coordinate]string)
+	for cell, shot := range g.Shots[team] {
+		cells[cell] = "M"
+		if shot.Hit {
+			cells[cell] = "H"
+		}
+	}
+	return cells
+}
+
+// View returns a copy of the game as a team sees it: every shot and the state of the game,
+// but only that team's fleet and commitment salt until they have been revealed. With no
+// team, no fleet or salt is shown.
+func (g *Game) View(team string) *Game {
+	v := g.Clone()
+	if v.Revealed {
+		return v
+	}
+	for t, board := range v.Boards {
+		if t != team {
+			board.Ships = nil
+		}
+	}
+	for t, c := range v.Commitments {
+		if t != team {
+			v.Commitments[t] = Commitment{Hash: c.Hash}
+		}
+	}
+	return v
+}
+
+// Clone returns a deep copy of the game that can be changed without affecting g
+func (g *Game) Clone() *Game {
+	c := *g
+	c.Settings.Fleet = append(Fleet(nil), g.Settings.Fleet...)
+	c.Boards = make(map[string]*Board, len(g.Boards))
+	for team, board := range g.Boards {
+		b := *board
+		b.Ships = append([]Ship(nil), board.Ships...)
+		c.Boards[team] = &b
+	}
+	c.Shots = make(map[string]map[Coordinate]Shot, len(g.Shots))
+	for team, shots := range g.Shots {
+		c.Shots[team] = make(map[Coordinate]Shot, len(shots))
+		for cell, shot := range shots {
+			c.Shots[team][cell] = shot
+		}
+	}
+	c.Joined = make(map[string]bool, len(g.Joined))
+	for team, joined := range g.Joined {
+		c.Joined[team] = joined
+	}
+	c.Commitments = make(map[string]Commitment, len(g.Commitments))
+	for team, commitment := range g.Commitments {
+		c.Commitments[team] = commitment
+	}
+	return &c
+}
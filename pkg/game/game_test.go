@@ -0,0 +1,246 @@
+package game
+
+import (
+	"errors"
+	"testing"
+)
+
+// newTestGame returns a game with a single destroyer at A0-B0 on each board and red to play
+func newTestGame(t *testing.T) *Game {
+	t.Helper()
+	g := New(Settings{Fleet: Fleet{{Name: "Destroyer", Length: 2}}, Width: 10, Height: 10})
+	for _, team := range Teams {
+		if err := g.Join(team); err != nil {
+			t.Fatalf("Join(%s) error = %v", team, err)
+		}
+		ship := Ship{Name: "Destroyer", Length: 2, Bow: Coordinate{X: 0, Y: 0}, Direction: Horizontal}
+		if err := g.PlaceShip(team, ship); err != nil {
+			t.Fatalf("PlaceShip(%s) error = %v", team, err)
+		}
+	}
+	g.FirstTeam, g.Turn = Red, Red
+	return g
+}
+
+func TestTossCoin(t *testing.T) {
+	g := New(DefaultSettings())
+	if err := g.Join(Red); err != nil {
+		t.Fatalf("Join(red) error = %v", err)
+	}
+	if first := g.TossCoin(); first != "" {
+		t.Fatalf("TossCoin() = %q with only one team joined, want empty", first)
+	}
+	if err := g.Join(Red); err == nil {
+		t.Error("Join(red) twice succeeded, want an error")
+	}
+
+	if err := g.Join(Blue); err != nil {
+		t.Fatalf("Join(blue) error = %v", err)
+	}
+	first := g.TossCoin()
+	if !ValidTeam(first) {
+		t.Fatalf("TossCoin() = %q, want red or blue", first)
+	}
+	if g.FirstTeam != first || g.Turn != first {
+		t.Errorf("after TossCoin() FirstTeam, Turn = %q, %q, want %q", g.FirstTeam, g.Turn, first)
+	}
+	if again := g.TossCoin(); again != "" {
+		t.Errorf("second TossCoin() = %q, want empty", again)
+	}
+}
+
+func TestPlaceShipFleet(t *testing.T) {
+	tests := []struct {
+		name    string
+		ship    Ship
+		wantErr bool
+	}{
+		{"ship of the fleet", Ship{Name: "Carrier", Length: 5}, false},
+		{"length not in the fleet", Ship{Name: "Dinghy", Length: 1}, true},
+		{"name not in the fleet", Ship{Name: "A", Length: 5}, true},
+		{"length of another ship", Ship{Name: "Destroyer", Length: 5}, true},
+	}
+
+	for _, tt := range tests {
+		g := New(DefaultSettings())
+		err := g.PlaceShip(Red, tt.ship)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: PlaceShip() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+		if err != nil && !errors.Is(err, ErrInvalidPlacement) {
+			t.Errorf("%s: PlaceShip() error = %v, want it to wrap %v", tt.name, err, ErrInvalidPlacement)
+		}
+	}
+}
+
+func TestFire(t *testing.T) {
+	g := newTestGame(t)
+
+	moves := []struct {
+		team   string
+		target Coordinate
+		want   string
+		winner string
+	}{
+		{Red, Coordinate{X: 5, Y: 5}, "miss", ""},
+		{Blue, Coordinate{X: 0, Y: 0}, "hit", ""},
+		{Red, Coordinate{X: 0, Y: 0}, "hit", ""},
+		{Blue, Coordinate{X: 9, Y: 9}, "miss", ""},
+		{Red, Coordinate{X: 1, Y: 0}, "hit and sunk Destroyer", Red},
+	}
+
+	for i, move := range moves {
+		shot, err := g.Fire(move.team, move.target)
+		if err != nil {
+			t.Fatalf("Fire(%s, %s) error = %v", move.team, move.target, err)
+		}
+		if shot.Outcome() != move.want {
+			t.Errorf("Fire(%s, %s) outcome = %q, want %q", move.team, move.target, shot.Outcome(), move.want)
+		}
+		if shot.Winner != move.winner {
+			t.Errorf("Fire(%s, %s) winner = %q, want %q", move.team, move.target, shot.Winner, move.winner)
+		}
+		if shot.Move != i+1 || g.Move != i+1 {
+			t.Errorf("Fire(%s, %s) move = %d, game move = %d, want %d", move.team, move.target, shot.Move, g.Move, i+1)
+		}
+	}
+
+	if !g.Over() || g.Status() != StatusRedWon {
+		t.Errorf("Status() = %q, want %q", g.Status(), StatusRedWon)
+	}
+	if sunk := g.SunkShips(Blue); len(sunk) != 1 || sunk[0] != "Destroyer" {
+		t.Errorf("SunkShips(blue) = %v, want [Destroyer]", sunk)
+	}
+	if g.FleetDestroyed(Red) {
+		t.Error("FleetDestroyed(red) = true, want false")
+	}
+
+	cells := g.ShipCells(Red)
+	if cells[Coordinate{X: 0, Y: 0}] != "H" || cells[Coordinate{X: 1, Y: 0}] != "S" {
+		t.Errorf("ShipCells(red) = %v, want A0 hit and B0 undamaged", cells)
+	}
+	shots := g.ShotCells(Red)
+	if shots[Coordinate{X: 5, Y: 5}] != "M" || shots[Coordinate{X: 1, Y: 0}] != "H" {
+		t.Errorf("ShotCells(red) = %v, want F5 missed and B0 hit", shots)
+	}
+}
+
+func TestFireErrors(t *testing.T) {
+	g := newTestGame(t)
+
+	if _, err := g.Fire(Blue, Coordinate{X: 3, Y: 3}); !errors.Is(err, ErrNotYourTurn) {
+		t.Errorf("Fire() out of turn error = %v, want %v", err, ErrNotYourTurn)
+	}
+	if _, err := g.Fire(Red, Coordinate{X: 10, Y: 3}); !errors.Is(err, ErrOffBoard) {
+		t.Errorf("Fire() off the board error = %v, want %v", err, ErrOffBoard)
+	}
+
+	if _, err := g.Fire(Red, Coordinate{X: 3, Y: 3}); err != nil {
+		t.Fatalf("Fire() error = %v", err)
+	}
+	g.Turn = Red
+	if _, err := g.Fire(Red, Coordinate{X: 3, Y: 3}); !errors.Is(err, ErrAlreadyShot) {
+		t.Errorf("Fire() on the same cell error = %v, want %v", err, ErrAlreadyShot)
+	}
+	if g.Move != 1 {
+		t.Errorf("Move = %d after rejected shots, want 1", g.Move)
+	}
+
+	g.Winner = Blue
+	if _, err := g.Fire(Red, Coordinate{X: 4, Y: 4}); !errors.Is(err, ErrGameOver) {
+		t.Errorf("Fire() after the game ended error = %v, want %v", err, ErrGameOver)
+	}
+}
+
+func TestTakeback(t *testing.T) {
+	g := newTestGame(t)
+	if err := g.RequestTakeback(Red); !errors.Is(err, ErrNothingToTakeBack) {
+		t.Errorf("RequestTakeback() before any move error = %v, want %v", err, ErrNothingToTakeBack)
+	}
+
+	if _, err := g.Fire(Red, Coordinate{X: 3, Y: 3}); err != nil {
+		t.Fatalf("Fire() error = %v", err)
+	}
+	if err := g.RequestTakeback(Blue); !errors.Is(err, ErrNothingToTakeBack) {
+		t.Errorf("RequestTakeback() of the opponent's move error = %v, want %v", err, ErrNothingToTakeBack)
+	}
+	if err := g.RequestTakeback(Red); err != nil {
+		t.Fatalf("RequestTakeback() error = %v", err)
+	}
+	if err := g.RequestTakeback(Red); !errors.Is(err, ErrTakebackPending) {
+		t.Errorf("second RequestTakeback() error = %v, want %v", err, ErrTakebackPending)
+	}
+	if err := g.AnswerTakeback(Red); !errors.Is(err, ErrNoTakeback) {
+		t.Errorf("AnswerTakeback() of its own request error = %v, want %v", err, ErrNoTakeback)
+	}
+	if err := g.AnswerTakeback(Blue); err != nil {
+		t.Fatalf("AnswerTakeback() error = %v", err)
+	}
+	if g.Takeback != "" {
+		t.Errorf("Takeback = %q after the request was answered, want empty", g.Takeback)
+	}
+
+	// Moving instead of answering lets the request lapse
+	if err := g.RequestTakeback(Red); err != nil {
+		t.Fatalf("RequestTakeback() error = %v", err)
+	}
+	if _, err := g.Fire(Blue, Coordinate{X: 3, Y: 3}); err != nil {
+		t.Fatalf("Fire() error = %v", err)
+	}
+	if g.Takeback != "" {
+		t.Errorf("Takeback = %q after the opponent moved, want empty", g.Takeback)
+	}
+}
+
+func TestResign(t *testing.T) {
+	g := newTestGame(t)
+	if _, err := g.Fire(Red, Coordinate{X: 3, Y: 3}); err != nil {
+		t.Fatalf("Fire() error = %v", err)
+	}
+	if err := g.RequestTakeback(Red); err != nil {
+		t.Fatalf("RequestTakeback() error = %v", err)
+	}
+
+	// Either team may resign, whoever is to play
+	if err := g.Resign(Red); err != nil {
+		t.Fatalf("Resign() error = %v", err)
+	}
+	if g.Winner != Blue || g.Resigned != Red || g.Status() != StatusBlueWon || g.Takeback != "" {
+		t.Errorf("after Resign(red) winner, resigned, takeback = %q, %q, %q, want blue, red and none", g.Winner, g.Resigned, g.Takeback)
+	}
+	if err := g.Resign(Blue); !errors.Is(err, ErrGameOver) {
+		t.Errorf("Resign() after the game ended error = %v, want %v", err, ErrGameOver)
+	}
+	if _, err := g.Fire(Blue, Coordinate{X: 0, Y: 0}); !errors.Is(err, ErrGameOver) {
+		t.Errorf("Fire() after resigning error = %v, want %v", err, ErrGameOver)
+	}
+}
+
+func TestView(t *testing.T) {
+	g := newTestGame(t)
+	if _, err := g.Fire(Red, Coordinate{X: 0, Y: 0}); err != nil {
+		t.Fatalf("Fire() error = %v", err)
+	}
+
+	tests := []struct {
+		team      string
+		red, blue int
+	}{
+		{Red, 1, 0},
+		{Blue, 0, 1},
+		{"", 0, 0},
+	}
+
+	for _, tt := range tests {
+		v := g.View(tt.team)
+		if len(v.Boards[Red].Ships) != tt.red || len(v.Boards[Blue].Ships) != tt.blue {
+			t.Errorf("View(%q) has %d red and %d blue ships, want %d and %d", tt.team, len(v.Boards[Red].Ships), len(v.Boards[Blue].Ships), tt.red, tt.blue)
+		}
+		if shot, ok := v.Shots[Red][Coordinate{X: 0, Y: 0}]; !ok || !shot.Hit {
+			t.Errorf("View(%q) does not show red's hit at A0", tt.team)
+		}
+	}
+	if len(g.Boards[Blue].Ships) != 1 {
+		t.Error("View() changed the fleets of the game")
+	}
+}
@@ -0,0 +1,102 @@
+package game
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// CheckCommit compares the state of a game before and after a commit and returns a
+// description of every change the rules do not allow. fleets holds each team's ships and
+// is used to check the result recorded for a new shot; a team missing from fleets has its
+// shots checked only for turn order.
+func CheckCommit(before, after *Game, fleets map[string][]Ship) []string {
+	var problems []string
+	if !reflect.DeepEqual(before.Settings, after.Settings) {
+		problems = append(problems, "the game settings were changed")
+	}
+
+	for _, team := range Teams {
+		if c, ok := before.Commitments[team]; ok && after.Commitments[team].Hash != c.Hash {
+			problems = append(problems, fmt.Sprintf("the %s layout commitment was changed after it was published", team))
+		}
+		if before.Revealed && !reflect.DeepEqual(before.Boards[team].Ships, after.Boards[team].Ships) {
+			problems = append(problems, fmt.Sprintf("the revealed %s fleet was changed", team))
+		}
+		if !after.Revealed && len(after.Boards[team].Ships) > 0 {
+			problems = append(problems, fmt.Sprintf("the %s fleet was published before the game ended", team))
+		}
+	}
+
+	// Recorded shots never change; each commit adds at most one
+	var added []Shot
+	for _, team := range Teams {
+		for cell, shot := range before.Shots[team] {
+			now, ok := after.Shots[team][cell]
+			if !ok || now.Hit != shot.Hit || now.Sunk != shot.Sunk {
+				problems = append(problems, fmt.Sprintf("the %s team's shot at %s was changed after it was recorded", team, cell))
+			}
+		}
+		for cell, shot := range after.Shots[team] {
+			if _, ok := before.Shots[team][cell]; !ok {
+				added = append(added, shot)
+			}
+		}
+	}
+
+	switch {
+	case len(added) > 1:
+		problems = append(problems, fmt.Sprintf("%d shots were recorded in one commit", len(added)))
+	case len(added) == 1:
+		problems = append(problems, checkShot(before, after, added[0], fleets)...)
+	default:
+		problems = append(problems, checkUnchanged(before, after)...)
+	}
+	return problems
+}
+
+// checkShot replays a shot added by a commit on the state before it and compares the
+// result and the new turn, move count and winner with what was recorded
+func checkShot(before, after *Game, shot Shot, fleets map[string][]Ship) []string {
+	replay := before.Clone()
+	for team, ships := range fleets {
+		replay.Boards[team].Ships = append([]Ship(nil), ships...)
+	}
+
+	want, err := replay.Fire(shot.Team, shot.Target)
+	if err != nil {
+		return []string{fmt.Sprintf("the %s team's shot at %s is not allowed: %v", shot.Team, shot.Target, err)}
+	}
+
+	var problems []string
+	if _, ok := fleets[Opponent(shot.Team)]; ok {
+		if want.Hit != shot.Hit || want.Sunk != shot.Sunk {
+			problems = append(problems, fmt.Sprintf("the %s team's shot at %s was recorded as a %s but the fleet gives a %s",
+				shot.Team, shot.Target, shot.Outcome(), want.Outcome()))
+		}
+		if after.Winner != replay.Winner {
+			problems = append(problems, fmt.Sprintf("the winner was recorded as %q but the shot gives %q", after.Winner, replay.Winner))
+		}
+	}
+	if after.Turn != replay.Turn || after.Move != replay.Move {
+		problems = append(problems, fmt.Sprintf("move %d was recorded with %s to play next, want move %d with %s to play",
+			after.Move, after.Turn, replay.Move, replay.Turn))
+	}
+	return problems
+}
+
+// checkUnchanged checks a commit without a shot: the coin toss may set the turn and a
+// resignation may decide the winner, but the turn, move count and winner cannot otherwise change
+func checkUnchanged(before, after *Game) []string {
+	var problems []string
+	if after.Move != before.Move {
+		problems = append(problems, fmt.Sprintf("the move count changed from %d to %d without a shot", before.Move, after.Move))
+	}
+	if before.Turn != "" && after.Turn != before.Turn {
+		problems = append(problems, fmt.Sprintf("the turn passed from %s to %s without a shot", before.Turn, after.Turn))
+	}
+	resigned := before.Resigned == "" && after.Resigned != "" && after.Winner == Opponent(after.Resigned)
+	if after.Winner != before.Winner && !resigned {
+		problems = append(problems, fmt.Sprintf("the winner was changed to %q without a winning shot", after.Winner))
+	}
+	return problems
+}
@@ -0,0 +1,69 @@
+package game
+
+import "testing"
+
+func TestCheckCommit(t *testing.T) {
+	// The shared state of a game with red to play, as the referee sees it, and the fleets
+	// kept on each team's private branch
+	shared := newTestGame(t).View("")
+	fleets := map[string][]Ship{
+		Red:  newTestGame(t).Boards[Red].Ships,
+		Blue: newTestGame(t).Boards[Blue].Ships,
+	}
+
+	// fire records a shot and the turn, move count and winner after it
+	fire := func(g *Game, team string, target Coordinate, hit bool, sunk string) {
+		g.Move++
+		g.Shots[team][target] = Shot{Team: team, Target: target, Hit: hit, Sunk: sunk}
+		g.Turn = Opponent(team)
+	}
+
+	tests := []struct {
+		name   string
+		change func(g *Game)
+		legal  bool
+	}{
+		{"no change", func(g *Game) {}, true},
+		{"miss", func(g *Game) { fire(g, Red, Coordinate{X: 5, Y: 5}, false, "") }, true},
+		{"hit", func(g *Game) { fire(g, Red, Coordinate{X: 0, Y: 0}, true, "") }, true},
+		{"resignation", func(g *Game) { g.Resigned, g.Winner = Red, Blue }, true},
+		{"out of turn", func(g *Game) { fire(g, Blue, Coordinate{X: 5, Y: 5}, false, "") }, false},
+		{"two shots", func(g *Game) {
+			fire(g, Red, Coordinate{X: 5, Y: 5}, false, "")
+			fire(g, Red, Coordinate{X: 6, Y: 5}, false, "")
+		}, false},
+		{"miss recorded as hit", func(g *Game) { fire(g, Red, Coordinate{X: 5, Y: 5}, true, "") }, false},
+		{"hit recorded as miss", func(g *Game) { fire(g, Red, Coordinate{X: 0, Y: 0}, false, "") }, false},
+		{"turn kept", func(g *Game) {
+			fire(g, Red, Coordinate{X: 5, Y: 5}, false, "")
+			g.Turn = Red
+		}, false},
+		{"turn passed without a shot", func(g *Game) { g.Turn = Blue }, false},
+		{"winner without a shot", func(g *Game) { g.Winner = Red }, false},
+		{"fleet published", func(g *Game) { g.Boards[Blue].Ships = fleets[Blue] }, false},
+		{"commitment changed", func(g *Game) { g.Commitments[Red] = Commitment{Hash: "changed"} }, false},
+	}
+
+	for _, tt := range tests {
+		before := shared.Clone()
+		before.Commitments[Red] = Commitment{Hash: "committed"}
+		after := before.Clone()
+		tt.change(after)
+
+		problems := CheckCommit(before, after, fleets)
+		if legal := len(problems) == 0; legal != tt.legal {
+			t.Errorf("%s: CheckCommit() = %q, want legal = %v", tt.name, problems, tt.legal)
+		}
+	}
+}
+
+func TestCheckCommitChangedShot(t *testing.T) {
+	before := newTestGame(t).View("")
+	before.Shots[Red][Coordinate{X: 5, Y: 5}] = Shot{Team: Red, Target: Coordinate{X: 5, Y: 5}}
+	after := before.Clone()
+	after.Shots[Red][Coordinate{X: 5, Y: 5}] = Shot{Team: Red, Target: Coordinate{X: 5, Y: 5}, Hit: true}
+
+	if problems := CheckCommit(before, after, nil); len(problems) != 1 {
+		t.Errorf("CheckCommit() of a changed shot = %q, want one problem", problems)
+	}
+}
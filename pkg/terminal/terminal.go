@@ -2,8 +2,12 @@ package terminal
 
 import (
 	"fmt"
+	"io"
 	"os"
+	"strconv"
 	"strings"
+
+	"battleship/pkg/game"
 )
 
 // Colors for terminal output
@@ -16,20 +20,22 @@ const (
 )
 
 // Coordinate represents a position on the battleship board
-type Coordinate struct {
-	X int
-	Y int
-}
+type Coordinate = game.Coordinate
 
 // Terminal handles colored output to the terminal
 type Terminal struct {
-	output *os.File
+	output io.Writer
 }
 
 // New creates a new Terminal instance
 func New() *Terminal {
+	return NewWriter(os.Stdout)
+}
+
+// NewWriter creates a new Terminal instance that writes to w
+func NewWriter(w io.Writer) *Terminal {
 	return &Terminal{
-		output: os.Stdout,
+		output: w,
 	}
 }
 
@@ -49,15 +55,45 @@ func (t *Terminal) PrintSuccess(msg string) {
 	fmt.Fprintf(t.output, "%s%s%s\n", Green, msg, Reset)
 }
 
+// PrintGameOver displays the outcome of a finished game from the point of view of the given
+// team. resigned names the team that resigned, if the game ended that way.
+func (t *Terminal) PrintGameOver(winner, resigned, team string) {
+	switch {
+	case resigned != "" && team == winner:
+		fmt.Fprintf(t.output, "%sVictory! The %s team resigned.%s\n", Green, resigned, Reset)
+	case resigned != "" && team == resigned:
+		fmt.Fprintf(t.output, "%sDefeat. You resigned and the %s team has won.%s\n", Red, winner, Reset)
+	case resigned != "":
+		fmt.Fprintf(t.output, "%sGame over: the %s team resigned and the %s team has won.%s\n", Yellow, resigned, winner, Reset)
+	case team == winner:
+		fmt.Fprintf(t.output, "%sVictory! You sank the entire enemy fleet.%s\n", Green, Reset)
+	case team == "":
+		fmt.Fprintf(t.output, "%sGame over: the %s team has won.%s\n", Yellow, winner, Reset)
+	default:
+		fmt.Fprintf(t.output, "%sDefeat. Your fleet has been destroyed by the %s team.%s\n", Red, winner, Reset)
+	}
+}
+
 // ClearScreen clears the terminal screen
 func ClearScreen() {
-	fmt.Print("\033[H\033[2J")
+	New().Clear()
 }
 
-// PrintBoards displays both the player's board and the opponent's board side by side
-func (t *Terminal) PrintBoards(myShips, opponentShots, myShots map[Coordinate]string, team string) {
+// Clear clears the screen the terminal writes to
+func (t *Terminal) Clear() {
+	fmt.Fprint(t.output, "\033[H\033[2J")
+}
+
+// PrintBoards displays both the player's board and the opponent's board side by side.
+// Columns are lettered from A and rows are numbered from 0.
+func (t *Terminal) PrintBoards(width, height int, myShips, opponentShots, myShots map[Coordinate]string, team string) {
 	spaceWidth := strings.Repeat(" ", 10)
 
+	// Row numbers are right-aligned to the widest one
+	labelWidth := len(strconv.Itoa(height - 1))
+	margin := strings.Repeat(" ", labelWidth+1)
+	border := strings.Repeat("-", 2*width)
+
 	// Print board labels based on team
 	var leftLabel, rightLabel string
 	switch team {
@@ -74,24 +110,24 @@ func (t *Terminal) PrintBoards(myShips, opponentShots, myShots map[Coordinate]st
 	fmt.Fprintf(t.output, "%s%s%s\n", leftLabel, spaceWidth, rightLabel)
 
 	// Print column headers for both boards
-	fmt.Fprintf(t.output, "  ")
-	for col := 'A'; col <= 'J'; col++ {
-		fmt.Fprintf(t.output, "%c ", col)
+	fmt.Fprint(t.output, margin)
+	for col := 0; col < width; col++ {
+		fmt.Fprintf(t.output, "%c ", 'A'+col)
 	}
-	fmt.Fprintf(t.output, "            ")
-	for col := 'A'; col <= 'J'; col++ {
-		fmt.Fprintf(t.output, "%c ", col)
+	fmt.Fprint(t.output, spaceWidth, margin)
+	for col := 0; col < width; col++ {
+		fmt.Fprintf(t.output, "%c ", 'A'+col)
 	}
 	fmt.Fprintln(t.output)
 
 	// Print top borders
-	fmt.Fprintf(t.output, "  %s%s  %s\n", strings.Repeat("-", 20), spaceWidth, strings.Repeat("-", 20))
+	fmt.Fprintf(t.output, "%s%s%s%s%s\n", margin, border, spaceWidth, margin, border)
 
 	// Print rows for both boards
-	for row := 0; row < 10; row++ {
+	for row := 0; row < height; row++ {
 		// Print row number and first board
-		fmt.Fprintf(t.output, "%d|", row)
-		for col := 0; col < 10; col++ {
+		fmt.Fprintf(t.output, "%*d|", labelWidth, row)
+		for col := 0; col < width; col++ {
 			coord := Coordinate{X: col, Y: row}
 			if value, exists := myShips[coord]; exists {
 				switch value {
@@ -117,11 +153,11 @@ func (t *Terminal) PrintBoards(myShips, opponentShots, myShots map[Coordinate]st
 		}
 
 		// Print separator between boards
-		fmt.Fprintf(t.output, spaceWidth)
+		fmt.Fprint(t.output, spaceWidth)
 
 		// Print row number and second board
-		fmt.Fprintf(t.output, "%d|", row)
-		for col := 0; col < 10; col++ {
+		fmt.Fprintf(t.output, "%*d|", labelWidth, row)
+		for col := 0; col < width; col++ {
 			coord := Coordinate{X: col, Y: row}
 			if value, exists := myShots[coord]; exists {
 				switch value {
@@ -139,6 +175,6 @@ func (t *Terminal) PrintBoards(myShips, opponentShots, myShots map[Coordinate]st
 		fmt.Fprintln(t.output)
 
 		// Print bottom borders
-		fmt.Fprintf(t.output, "  %s  %s%s\n", strings.Repeat("-", 20), spaceWidth, strings.Repeat("-", 20))
+		fmt.Fprintf(t.output, "%s%s%s%s%s\n", margin, border, spaceWidth, margin, border)
 	}
 }